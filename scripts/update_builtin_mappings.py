@@ -0,0 +1,174 @@
+#!/usr/bin/env python3
+"""Refresh the vendored builtin package mappings from chainguard-dev/dfc."""
+
+import argparse
+import hashlib
+import os
+import sys
+import tempfile
+from pathlib import Path
+
+import httpx
+import yaml
+
+DEFAULT_URL = (
+    "https://raw.githubusercontent.com/chainguard-dev/dfc/main/pkg/dfc/builtin-mappings.yaml"
+)
+
+
+def validate_mappings(data: object) -> list[str]:
+    """Check that parsed mappings match the schema used by map_package.
+
+    Returns a list of problems (empty if the mappings are valid).
+    """
+    if not isinstance(data, dict):
+        return ["top level is not a mapping"]
+
+    problems: list[str] = []
+
+    images = data.get("images")
+    if not isinstance(images, dict):
+        problems.append("'images' section is missing or not a mapping")
+
+    packages = data.get("packages")
+    if not isinstance(packages, dict):
+        problems.append("'packages' section is missing or not a mapping")
+        return problems
+
+    for distro, entries in packages.items():
+        if entries is None:
+            continue
+        if not isinstance(entries, dict):
+            problems.append(f"packages.{distro} is not a mapping")
+            continue
+        for name, targets in entries.items():
+            if targets is not None and not (
+                isinstance(targets, list) and all(isinstance(t, str) for t in targets)
+            ):
+                problems.append(f"packages.{distro}.{name} is not a list of package names")
+
+    return problems
+
+
+def _flatten(data: dict) -> dict[str, str]:
+    """Flatten mappings to "section.key" -> value strings for comparison."""
+    flat: dict[str, str] = {}
+    for image, target in (data.get("images") or {}).items():
+        flat[f"images.{image}"] = str(target)
+    for distro, entries in (data.get("packages") or {}).items():
+        for name, targets in (entries or {}).items():
+            flat[f"packages.{distro}.{name}"] = " ".join(targets or [])
+    return flat
+
+
+def print_changes(old: dict, new: dict) -> None:
+    """Print added, removed, and changed mapping entries."""
+    old_flat = _flatten(old)
+    new_flat = _flatten(new)
+
+    added = sorted(new_flat.keys() - old_flat.keys())
+    removed = sorted(old_flat.keys() - new_flat.keys())
+    changed = sorted(k for k in old_flat.keys() & new_flat.keys() if old_flat[k] != new_flat[k])
+
+    for key in added:
+        print(f"+ {key}: {new_flat[key]}")
+    for key in removed:
+        print(f"- {key}: {old_flat[key]}")
+    for key in changed:
+        print(f"~ {key}: {old_flat[key]} -> {new_flat[key]}")
+
+    print(f"{len(added)} added, {len(removed)} removed, {len(changed)} changed")
+
+
+def update_mappings(url: str, output_path: Path, sha256: str | None, dry_run: bool) -> int:
+    """Download, verify, and atomically replace the builtin mappings file.
+
+    sha256 may only be omitted for a dry run.
+    """
+    try:
+        response = httpx.get(url, timeout=30.0, follow_redirects=True)
+        response.raise_for_status()
+    except httpx.HTTPError as e:
+        print(f"Error: failed to download {url}: {e}", file=sys.stderr)
+        return 1
+
+    content = response.content
+
+    digest = hashlib.sha256(content).hexdigest()
+    if sha256 is not None and digest != sha256.lower():
+        print(f"Error: checksum mismatch (expected {sha256}, got {digest})", file=sys.stderr)
+        return 1
+
+    try:
+        new = yaml.safe_load(content)
+    except yaml.YAMLError as e:
+        print(f"Error: downloaded mappings are not valid YAML: {e}", file=sys.stderr)
+        return 1
+
+    problems = validate_mappings(new)
+    if problems:
+        for problem in problems:
+            print(f"Error: {problem}", file=sys.stderr)
+        return 1
+
+    old: dict = {}
+    if output_path.exists():
+        with open(output_path) as f:
+            old = yaml.safe_load(f) or {}
+
+    print_changes(old, new)
+    print(f"sha256: {digest}")
+
+    if dry_run:
+        return 0
+
+    if sha256 is None:
+        print("Error: refusing to replace mappings without a --sha256 checksum", file=sys.stderr)
+        return 1
+
+    # Write to a temporary file in the same directory, then rename, so a partial
+    # download can never replace the vendored mappings
+    fd, tmp_name = tempfile.mkstemp(dir=output_path.parent, prefix=".builtin-mappings-")
+    try:
+        with os.fdopen(fd, "wb") as f:
+            f.write(content)
+        os.chmod(tmp_name, 0o644)
+        os.replace(tmp_name, output_path)
+    except BaseException:
+        Path(tmp_name).unlink(missing_ok=True)
+        raise
+
+    print(f"Wrote {output_path}")
+    return 0
+
+
+def main() -> None:
+    script_dir = Path(__file__).parent
+    project_root = script_dir.parent
+
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument("--url", default=DEFAULT_URL, help="URL of the dfc builtin-mappings.yaml")
+    parser.add_argument(
+        "--sha256",
+        help="Expected SHA-256 checksum of the downloaded file (required unless --dry-run)",
+    )
+    parser.add_argument(
+        "--dry-run",
+        action="store_true",
+        help="Show changes and checksum without replacing the file",
+    )
+    args = parser.parse_args()
+
+    # Never replace the vendored mappings with an unverified download
+    if args.sha256 is None and not args.dry_run:
+        parser.error(
+            "--sha256 is required to replace the mappings (use --dry-run to get the checksum)"
+        )
+
+    output_path = project_root / "src" / "dfc_shazam" / "builtin-mappings.yaml"
+
+    sys.exit(update_mappings(args.url, output_path, args.sha256, args.dry_run))
+
+
+if __name__ == "__main__":
+    main()
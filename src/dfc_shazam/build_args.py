@@ -0,0 +1,84 @@
+"""Expansion of Dockerfile ARG/ENV variable references."""
+
+import re
+from fnmatch import fnmatchcase
+
+# Matches $VAR, ${VAR}, ${VAR:-word}, ${VAR-word}, ${VAR:+word}, ${VAR+word}
+# and the pattern removal forms ${VAR%pattern}, ${VAR%%pattern}, ${VAR#pattern}, ${VAR##pattern}
+_VARIABLE_PATTERN = re.compile(
+    r"\$(?:\{(?P<braced>[A-Za-z_][A-Za-z0-9_]*)(?:(?P<op>:?[-+]|%%?|##?)(?P<word>[^}]*))?\}"
+    r"|(?P<bare>[A-Za-z_][A-Za-z0-9_]*))"
+)
+
+
+def has_variables(value: str) -> bool:
+    """Check if a value contains any Dockerfile variable references."""
+    return _VARIABLE_PATTERN.search(value) is not None
+
+
+def variable_references(value: str) -> list[str]:
+    """List the variable references in a value, as written (e.g., ["${PY%.*}"])."""
+    return [match.group(0) for match in _VARIABLE_PATTERN.finditer(value)]
+
+
+def _remove_pattern(value: str, pattern: str, op: str) -> str:
+    """Remove a glob pattern from the start (#, ##) or end (%, %%) of a value.
+
+    Single operators remove the shortest match, doubled operators the longest.
+    """
+    if op in ("#", "##"):
+        ends = range(len(value) + 1) if op == "#" else range(len(value), -1, -1)
+        for end in ends:
+            if fnmatchcase(value[:end], pattern):
+                return value[end:]
+    else:
+        starts = range(len(value), -1, -1) if op == "%" else range(len(value) + 1)
+        for start in starts:
+            if fnmatchcase(value[start:], pattern):
+                return value[:start]
+    return value
+
+
+def expand_build_args(value: str, build_args: dict[str, str]) -> tuple[str, list[str]]:
+    """Expand Dockerfile variable references using known ARG/ENV values.
+
+    Follows Dockerfile modifier semantics: `${VAR:-word}` falls back to word when
+    VAR is unset or empty, `${VAR-word}` only when unset, and `${VAR:+word}` /
+    `${VAR+word}` substitute word when VAR is set. `${VAR%pattern}` / `${VAR#pattern}`
+    remove the shortest matching suffix / prefix (`%%` / `##` the longest).
+
+    Examples:
+        ("${BASE}", {"BASE": "python:3.11"}) -> ("python:3.11", [])
+        ("python:${PY:-3.12}-slim", {}) -> ("python:3.12-slim", [])
+        ("python${PY%.*}", {"PY": "3.11.8"}) -> ("python3.11", [])
+        ("$REGISTRY/node:18", {}) -> ("/node:18", ["REGISTRY"])
+
+    Returns:
+        Tuple of (expanded value, names of variables that could not be resolved).
+    """
+    unresolved: list[str] = []
+
+    def replace(match: re.Match[str]) -> str:
+        name = match.group("braced") or match.group("bare")
+        op = match.group("op")
+        word = match.group("word") or ""
+        current = build_args.get(name)
+
+        if op in (":-", "-"):
+            if current is None or (op == ":-" and current == ""):
+                return word
+            return current
+        if op in (":+", "+"):
+            if current is None or (op == ":+" and current == ""):
+                return ""
+            return word
+
+        if current is None:
+            unresolved.append(name)
+            return ""
+        if op:
+            return _remove_pattern(current, word, op)
+        return current
+
+    expanded = _VARIABLE_PATTERN.sub(replace, value)
+    return expanded, list(dict.fromkeys(unresolved))
@@ -14,6 +14,37 @@ class Settings(BaseSettings):
     # chainctl timeout
     chainctl_timeout_seconds: int = 30
 
+    # Optional YAML file with user-defined image overrides (see mappings/image_overrides.py)
+    image_overrides_file: str | None = None
+
+    # Default Chainguard organization, selected automatically instead of prompting
+    # when chainctl reports several organizations
+    org: str | None = None
+
+    # Optional mirror of cgr.dev/{org} (e.g., "registry.internal.example.com/cg-mirror").
+    # Image references for the Dockerfile are rewritten to use it.
+    registry: str | None = None
+
+    @property
+    def mirror_registry(self) -> str | None:
+        """Get the configured mirror registry without a trailing slash, or None if unset."""
+        if not self.registry:
+            return None
+        return self.registry.rstrip("/")
+
+    def mirror_image_ref(self, image_ref: str, org: str) -> str | None:
+        """Rewrite a cgr.dev/{org}/ image reference to the configured mirror registry.
+
+        Returns None if no mirror is configured or the reference is not under cgr.dev/{org}/.
+        """
+        mirror_registry = self.mirror_registry
+        if mirror_registry is None:
+            return None
+        prefix = f"cgr.dev/{org}/"
+        if not image_ref.startswith(prefix):
+            return None
+        return f"{mirror_registry}/{image_ref[len(prefix):]}"
+
     @property
     def chainguard_org(self) -> str:
         """Get the selected Chainguard organization.
@@ -52,6 +83,8 @@ class OrgSession:
     _available_orgs: list[str] | None = None
     # Cache for image probing results: {image_ref: (has_shell, has_apk)}
     _image_capabilities_cache: dict[str, tuple[bool, bool]] = {}
+    # Cache for resolved digests: {image_ref: digest}
+    _digest_cache: dict[str, str] = {}
 
     @classmethod
     def get_org(cls) -> str | None:
@@ -62,11 +95,12 @@ class OrgSession:
     def set_org(cls, org: str) -> None:
         """Set the selected organization.
 
-        Note: Changing the organization clears the image capabilities cache
-        since image references are org-specific.
+        Note: Changing the organization clears the image capabilities and digest
+        caches since image references are org-specific.
         """
         if org != cls._selected_org:
             cls._image_capabilities_cache.clear()
+            cls._digest_cache.clear()
         cls._selected_org = org
 
     @classmethod
@@ -99,9 +133,20 @@ class OrgSession:
         """Cache image capabilities."""
         cls._image_capabilities_cache[image_ref] = (has_shell, has_apk)
 
+    @classmethod
+    def get_digest(cls, image_ref: str) -> str | None:
+        """Get a cached digest for an image reference, or None if not cached."""
+        return cls._digest_cache.get(image_ref)
+
+    @classmethod
+    def set_digest(cls, image_ref: str, digest: str) -> None:
+        """Cache the resolved digest for an image reference."""
+        cls._digest_cache[image_ref] = digest
+
     @classmethod
     def clear(cls) -> None:
         """Clear the session state."""
         cls._selected_org = None
         cls._available_orgs = None
         cls._image_capabilities_cache.clear()
+        cls._digest_cache.clear()
@@ -2,12 +2,14 @@
 
 from dfc_shazam.mappings.images import (
     ImageMatch,
+    detect_package_manager,
     is_generic_base_image,
     lookup_chainguard_image,
 )
 
 __all__ = [
     "ImageMatch",
+    "detect_package_manager",
     "is_generic_base_image",
     "lookup_chainguard_image",
 ]
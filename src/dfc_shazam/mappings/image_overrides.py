@@ -0,0 +1,136 @@
+"""User-defined image overrides that take precedence over the builtin image mappings.
+
+Overrides are read from the YAML file named by DFC_SHAZAM_IMAGE_OVERRIDES_FILE:
+
+    images:
+      "docker.io/library/python:*": cgr.dev/my-org/python:3.12-dev
+      "node:18*": registry.internal/mirror/node:18
+    stages:
+      builder: cgr.dev/my-org/go:1.22-dev
+
+Keys under `images` are glob patterns matched against the source image reference.
+Keys under `stages` are Dockerfile stage names (`FROM ... AS <name>`) and win over
+image patterns.
+"""
+
+from dataclasses import dataclass
+from fnmatch import fnmatchcase
+from pathlib import Path
+
+import yaml
+
+from dfc_shazam.config import settings
+
+
+@dataclass
+class ImageOverride:
+    """A matched override rule."""
+
+    target_image: str
+    rule: str  # e.g. "images['python:*']" or "stages['builder']"
+    source_file: str
+
+
+class ImageOverridesError(Exception):
+    """Raised when the overrides file cannot be read or is malformed."""
+
+    pass
+
+
+def _load_overrides(path: Path) -> dict:
+    """Load and validate the overrides file."""
+    try:
+        with open(path) as f:
+            data = yaml.safe_load(f) or {}
+    except OSError as e:
+        raise ImageOverridesError(f"Failed to read image overrides file {path}: {e}")
+    except yaml.YAMLError as e:
+        raise ImageOverridesError(f"Invalid YAML in image overrides file {path}: {e}")
+
+    if not isinstance(data, dict):
+        raise ImageOverridesError(f"Image overrides file {path} must contain a mapping")
+
+    for section in ("images", "stages"):
+        entries = data.get(section) or {}
+        if not isinstance(entries, dict) or not all(
+            isinstance(k, str) and isinstance(v, str) for k, v in entries.items()
+        ):
+            raise ImageOverridesError(
+                f"'{section}' in image overrides file {path} must map strings to image references"
+            )
+
+    return data
+
+
+def _candidate_references(source_image: str) -> list[str]:
+    """Expand a source image into the equivalent forms a pattern may be written against.
+
+    A digest is stripped and a missing tag is treated as ':latest'. Docker Hub references
+    are normalized to their canonical form and every shorter spelling is generated from it,
+    so a pattern matches however either side is written.
+
+    Examples:
+        "python:3.12" -> ["python:3.12", "docker.io/library/python:3.12",
+                          "library/python:3.12"]
+        "docker.io/library/python:3.12" -> ["docker.io/library/python:3.12",
+                                            "library/python:3.12", "python:3.12"]
+        "python@sha256:abc" -> ["python:latest", "docker.io/library/python:latest",
+                                "library/python:latest"]
+        "bitnami/redis" -> ["bitnami/redis:latest", "docker.io/bitnami/redis:latest"]
+        "ghcr.io/org/app:1" -> ["ghcr.io/org/app:1"]
+    """
+    reference = source_image.split("@")[0]
+    if ":" not in reference.rsplit("/", 1)[-1]:
+        reference = f"{reference}:latest"
+
+    first_part = reference.split("/")[0]
+    has_registry = "/" in reference and (
+        "." in first_part or ":" in first_part or first_part == "localhost"
+    )
+    if not has_registry:
+        path = reference
+    elif first_part in ("docker.io", "index.docker.io"):
+        path = reference.split("/", 1)[1]
+    else:
+        return [reference]
+
+    if "/" not in path:
+        path = f"library/{path}"
+    candidates = [reference, f"docker.io/{path}", path]
+    if path.startswith("library/"):
+        candidates.append(path[len("library/"):])
+    return list(dict.fromkeys(candidates))
+
+
+def lookup_image_override(source_image: str, stage_name: str | None = None) -> ImageOverride | None:
+    """Look up a user-defined override for a source image.
+
+    Args:
+        source_image: Source image reference from the FROM or COPY --from line
+        stage_name: Optional stage name (from `FROM ... AS <name>`) for per-stage overrides
+
+    Returns:
+        The matching ImageOverride, or None if no overrides file is configured or no rule matches.
+
+    Raises:
+        ImageOverridesError: If the configured overrides file is unreadable or malformed.
+    """
+    if not settings.image_overrides_file:
+        return None
+
+    path = Path(settings.image_overrides_file).expanduser()
+    data = _load_overrides(path)
+
+    if stage_name:
+        stages = data.get("stages") or {}
+        for name, target in stages.items():
+            if name.lower() == stage_name.lower():
+                return ImageOverride(target, f"stages['{name}']", str(path))
+
+    images = data.get("images") or {}
+    candidates = _candidate_references(source_image)
+    for pattern, target in images.items():
+        if any(fnmatchcase(candidate, pattern) for candidate in candidates):
+            return ImageOverride(target, f"images['{pattern}']", str(path))
+
+    return None
@@ -17,6 +17,7 @@ STATIC_REGISTRY_PREFIXES = (
     "library/",
     "registry.access.redhat.com/",
     "registry.redhat.io/",
+    "registry.suse.com/",
     "quay.io/",
     "gcr.io/",
     "ghcr.io/",
@@ -44,6 +45,25 @@ DYNAMIC_REGISTRY_PATTERNS = (
 )
 
 
+# Tag fragments that identify the distro of language images (e.g., python:3.12-alpine)
+DISTRO_TAG_HINTS = (
+    ("alpine", "apk"),
+    ("bookworm", "apt"),
+    ("bullseye", "apt"),
+    ("buster", "apt"),
+    ("trixie", "apt"),
+    ("jammy", "apt"),
+    ("focal", "apt"),
+    ("noble", "apt"),
+    ("ubi", "dnf"),
+    ("centos", "yum"),
+    ("amazonlinux", "yum"),
+    ("al2023", "dnf"),
+    ("leap", "zypper"),
+    ("sles", "zypper"),
+)
+
+
 @dataclass
 class ImageMatch:
     """A matched Chainguard image with similarity score."""
@@ -54,14 +74,17 @@ class ImageMatch:
 
 
 @lru_cache
-def _load_generic_base_images() -> set[str]:
-    """Load generic base images from CSV."""
+def _load_generic_base_images() -> dict[str, str]:
+    """Load generic base images from CSV, returning image -> package manager.
+
+    The package manager is empty for images that don't ship one (e.g., scratch).
+    """
     csv_path = MAPPINGS_DIR / "generic_base_images.csv"
-    images: set[str] = set()
+    images: dict[str, str] = {}
     with open(csv_path) as f:
         reader = csv.DictReader(f)
         for row in reader:
-            images.add(row["image"])
+            images[row["image"]] = row["package_manager"]
     return images
 
 
@@ -182,7 +205,8 @@ def _normalize_image_name(source_image: str) -> str:
     Returns:
         Normalized image name (e.g., "ubi9/ubi-minimal")
     """
-    image_name = source_image.lower()
+    # Remove digest if present ("ubuntu@sha256:...")
+    image_name = source_image.lower().split("@")[0]
 
     # Remove tag if present (but be careful with ports like :5000)
     # Tags come after the last : that follows a /
@@ -232,6 +256,37 @@ def is_generic_base_image(source_image: str) -> bool:
     return False
 
 
+def detect_package_manager(source_image: str) -> str | None:
+    """Detect the package manager used by a source image.
+
+    Generic base images are looked up directly; other images are detected from
+    distro hints in their tag (e.g., "python:3.12-slim-bookworm" -> "apt").
+
+    Args:
+        source_image: Source image reference (e.g., "ubuntu:22.04", "node:18-alpine")
+
+    Returns:
+        Package manager name ("apt", "yum", "dnf", "apk", "zypper"), or None if unknown
+    """
+    image_name = _normalize_image_name(source_image)
+    generic_base_images = _load_generic_base_images()
+
+    for name in (image_name, image_name.split("/")[-1]):
+        if name in generic_base_images:
+            return generic_base_images[name] or None
+
+    tag = ""
+    last_part = source_image.lower().split("@")[0].rsplit("/", 1)[-1]
+    if ":" in last_part:
+        tag = last_part.split(":", 1)[1]
+
+    for hint, package_manager in DISTRO_TAG_HINTS:
+        if hint in tag:
+            return package_manager
+
+    return None
+
+
 def lookup_chainguard_image(
     source_image: str,
     fuzzy_threshold: float = 0.6,
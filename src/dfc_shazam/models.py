@@ -1,5 +1,7 @@
 """Pydantic models for dfc-shazam."""
 
+from typing import Literal
+
 from pydantic import BaseModel, Field
 
 
@@ -75,6 +77,16 @@ class ChainguardImageResult(BaseModel):
         description="Full image reference with tag (e.g., 'cgr.dev/org/python:3.12'). "
         "Use this value when calling get_migration_instructions_for_chainguard_image.",
     )
+    pinned_image_ref: str | None = Field(
+        default=None,
+        description="full_image_ref pinned by digest (e.g., 'cgr.dev/org/python:3.12@sha256:...'). "
+        "Only populated when pin_digest is requested. Use this in the FROM line.",
+    )
+    mirrored_image_ref: str | None = Field(
+        default=None,
+        description="pinned_image_ref or full_image_ref rewritten to the mirror registry from "
+        "DFC_SHAZAM_REGISTRY. Only populated when a mirror is configured. Use this in the FROM line.",
+    )
     variant: str | None = Field(
         default=None,
         description="The selected variant: 'distroless', 'slim', or 'dev'.",
@@ -103,6 +115,11 @@ class ChainguardImageResult(BaseModel):
         description="Guidance for multi-stage builds "
         "(e.g., how to copy artifacts from builder stage)",
     )
+    override_rule: str | None = Field(
+        default=None,
+        description="The user-defined override rule that selected this image "
+        "(e.g., \"images['python:*']\"), if any. Overrides bypass the builtin mappings.",
+    )
 
 
 class ImageConfig(BaseModel):
@@ -128,6 +145,18 @@ class ImageConfig(BaseModel):
         default_factory=list,
         description="Environment variables set in the image",
     )
+    exposed_ports: list[str] = Field(
+        default_factory=list,
+        description="Ports exposed by the image (e.g., '8080/tcp')",
+    )
+    volumes: list[str] = Field(
+        default_factory=list,
+        description="Volume mount points declared by the image",
+    )
+    stop_signal: str | None = Field(
+        default=None,
+        description="Signal sent to stop the container (e.g., 'SIGQUIT')",
+    )
     has_shell: bool = Field(
         default=False,
         description="True if /bin/sh or similar shell is available",
@@ -198,6 +227,21 @@ class PackageMappingResult(BaseModel):
         default=None,
         description="The recommended APK package name (highest scoring match)",
     )
+    pinned_version: str | None = Field(
+        default=None,
+        description="Version pin from the source package (e.g., '7.88.1-10+deb12u5')",
+    )
+    apk_constraint: str | None = Field(
+        default=None,
+        description="APK package spec preserving the version pin (e.g., 'curl=~7.88.1'). "
+        "Use this in `apk add` instead of best_match when set.",
+    )
+    mapping_source: Literal["group", "module-stream", "builtin", "index"] | None = Field(
+        default=None,
+        description="Which rule produced the mapping: 'group' (builtin group mappings), "
+        "'module-stream' (versioned package for a dnf module), 'builtin' (dfc builtin mappings), "
+        "or 'index' (exact/fuzzy search of the APK index). None if nothing matched.",
+    )
     message: str | None = None
 
 
@@ -211,6 +255,17 @@ class PackageMappingBatchResult(BaseModel):
     summary: str = Field(
         description="Summary of all mappings in a format suitable for Dockerfile conversion"
     )
+    unmapped: list[str] = Field(
+        default_factory=list,
+        description="Source packages with no usable APK mapping. These must be resolved "
+        "before the Dockerfile is converted. Packages that can simply be removed "
+        "(builtin mappings or groups with no APK equivalent) are not listed here.",
+    )
+    distro_detected_from: Literal["source_image", "run_commands"] | None = Field(
+        default=None,
+        description="How source_distro was selected when 'auto' was requested: from the stage's "
+        "base image or inferred from its RUN commands. None if it was given explicitly or not detected.",
+    )
 
 
 class PackageVerificationResult(BaseModel):
@@ -262,7 +317,7 @@ class ImageOverviewResult(BaseModel):
     )
     conversion_tips: list[str] = Field(
         default_factory=list,
-        description="General Dockerfile conversion tips applicable to all images",
+        description="Dockerfile conversion tips: general ones plus those for the image's ecosystem",
     )
     available_users: list[ContainerUserInfo] = Field(
         default_factory=list,
@@ -343,7 +398,7 @@ class MigrationInstructionsResult(BaseModel):
     )
     conversion_tips: list[str] = Field(
         default_factory=list,
-        description="General Dockerfile conversion tips applicable to all images",
+        description="Dockerfile conversion tips: general ones plus those for the image's ecosystem",
     )
     available_users: list[ContainerUserInfo] = Field(
         default_factory=list,
@@ -360,6 +415,12 @@ class MigrationInstructionsResult(BaseModel):
         default_factory=list,
         description="Content fetched from best practices and getting started links",
     )
+    conversion_label: str | None = Field(
+        default=None,
+        description="LABEL instruction to add to the final stage when add_conversion_label is set. "
+        "If the Dockerfile already has dev.dfc-shazam.* labels, update their values instead of adding "
+        "a second LABEL; merge into a LABEL that is already the last instruction.",
+    )
 
     message: str | None = Field(
         default=None, description="Additional messages or warnings"
@@ -22,7 +22,7 @@ mcp = FastMCP(
 RECOMMENDED TOOL WORKFLOW:
 1. find_equivalent_chainguard_image - Find the Chainguard image equivalent for a source image (handles org selection AND variant selection)
 2. get_migration_instructions_for_chainguard_image - Get migration guidance, verify image exists, and retrieve best practices
-3. find_equivalent_apk_packages - Map apt/yum package names to APK equivalents
+3. find_equivalent_apk_packages - Map apt/yum/zypper package names to APK equivalents
 4. validate_apk_packages_install - REQUIRED: Verify ALL mapped packages install correctly BEFORE editing the Dockerfile
 
 CRITICAL: Steps 3-4 are MANDATORY when the Dockerfile installs packages. Never edit a Dockerfile with package mappings until you have validated them with validate_apk_packages_install.
@@ -38,6 +38,7 @@ CRITICAL BEHAVIORAL REQUIREMENTS:
    - If find_equivalent_chainguard_image returns a list of organizations, you MUST show the FULL list to the user and ask them to choose one
    - Never summarize or truncate the organization list
    - Always use cgr.dev/{org}/<image> format, never cgr.dev/chainguard/<image>
+   - If mirrored_image_ref is returned, write it (not full_image_ref) into the Dockerfile, and rewrite other cgr.dev/{org}/ references in FROM and COPY --from the same way
 
 2. VARIANT SELECTION:
    - If find_equivalent_chainguard_image returns variant_capabilities without a matched_tag, you MUST ask the user which variant they need
@@ -69,11 +70,11 @@ CRITICAL BEHAVIORAL REQUIREMENTS:
 
 6. PACKAGE INSTALLATION IN DOCKERFILE:
    - Chainguard images run as non-root by default
-   - When installing packages with apk, switch to root first, then IMMEDIATELY switch back:
+   - When installing packages with apk, switch to root first (unless the stage is already running as root), then IMMEDIATELY switch back to the user that was active before:
      USER root
      RUN apk add --no-cache <packages>
-     USER nonroot  # ⚠️ MUST be on the VERY NEXT LINE after apk add!
-   - 🚨 CRITICAL: `USER nonroot` MUST appear IMMEDIATELY after `apk add`, on the very next line
+     USER nonroot  # ⚠️ VERY NEXT LINE after apk add - or the earlier user, e.g. USER app
+   - 🚨 CRITICAL: the USER line restoring the previous user (`nonroot` unless the Dockerfile switched to another user earlier) MUST appear IMMEDIATELY after `apk add`, on the very next line. If the Dockerfile already has a USER directive there, reuse it instead of adding a duplicate
    - Never leave subsequent Dockerfile instructions running as root - this is a security vulnerability
    - If you have multiple RUN commands after apk add, they will ALL run as root if you forget to switch back
 
@@ -87,6 +88,12 @@ CRITICAL BEHAVIORAL REQUIREMENTS:
 
      FROM cgr.dev/{org}/python:latest
      COPY --from=builder /home/nonroot/.local /home/nonroot/.local
+   - COPY/ADD --from=<stage name or index> must keep pointing at the same stage. Keep `AS <name>` aliases unchanged when rewriting FROM lines, and re-check numeric indices (--from=0) if stages are added or removed
+   - COPY/ADD --from=<external image> (e.g., --from=golang:1.22) is an image reference like FROM: map it with find_equivalent_chainguard_image too
+   - Stage names are case-insensitive: `FROM Builder` refers to an earlier `AS builder` stage, not an image - do NOT map it. If an alias is declared twice, the later stage wins
+   - A stage built FROM an earlier stage inherits that stage's distro: pass the root stage's base image as source_image to find_equivalent_apk_packages
+   - Keep FROM flags such as --platform=$BUILDPLATFORM verbatim and swap only the image reference. Leave ARG TARGETARCH/TARGETOS/TARGETPLATFORM declarations and their uses in download URLs intact
+   - Install locations can differ in Chainguard images - verify the copied source path exists using the filesystem_tree from get_migration_instructions_for_chainguard_image
 
 8. VARIANT CAPABILITIES:
    - Capabilities vary by image - always check variant_capabilities from find_equivalent_chainguard_image
@@ -104,6 +111,18 @@ CRITICAL BEHAVIORAL REQUIREMENTS:
    - For python/node: Use -dev variant for build, distroless for runtime (same image family)
    - Check multi_stage_guidance field for artifact copy instructions
 
+10. DOCKER COMPOSE AND BAKE:
+   - For docker-compose.yml, convert every Dockerfile referenced by a service's build section (build.dockerfile relative to build.context, or ./Dockerfile for `build: .`)
+   - Services with only an `image:` field reference a public image directly: map it with find_equivalent_chainguard_image
+   - If build.args sets a base image ARG, pass those values as build_args to find_equivalent_chainguard_image and update the arg value in the compose file
+   - Edit the compose file minimally - only change image references and args, preserving comments and key order
+   - docker-bake.hcl / docker-bake.json work the same way: convert each target's dockerfile (resolve `inherits` first to find the effective dockerfile and context), map `docker-image://` values in `contexts` with find_equivalent_chainguard_image, and update `args` that set base-image ARGs. Only change the string values that need it
+
+11. READINESS REVIEW (NO CONVERSION):
+   - If the user only wants to know how Chainguard-ready a Dockerfile is, run the same tools but do NOT edit any file
+   - Report per file: image mapping for each FROM, package coverage (the unmapped list from find_equivalent_apk_packages), shell-form CMD/ENTRYPOINT on images without a shell, a final stage running as root, and EXPOSE ports below 1024
+   - Mark each finding as error (blocks conversion, e.g., unmapped packages) or warning (needs review), and end with a short summary per file
+
 COMMON IMAGE EQUIVALENTS:
 - eclipse-temurin → adoptium-jdk or adoptium-jre
 - openjdk → jdk or jre
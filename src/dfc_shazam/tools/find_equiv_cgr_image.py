@@ -1,11 +1,14 @@
 """Tool for looking up Chainguard image equivalents."""
 
-from typing import Annotated, Any
+import re
+from typing import Annotated, Any, Literal
 
 from pydantic import Field
 
+from dfc_shazam.build_args import expand_build_args, has_variables
 from dfc_shazam.chainctl import ChainctlClient, ChainctlError
-from dfc_shazam.config import PUBLIC_REGISTRY, OrgSession
+from dfc_shazam.config import PUBLIC_REGISTRY, OrgSession, settings
+from dfc_shazam.mappings.image_overrides import ImageOverridesError, lookup_image_override
 from dfc_shazam.mappings.images import (
     is_generic_base_image,
     lookup_chainguard_image as lookup_image_matches,
@@ -60,6 +63,67 @@ def _parse_image_reference(source_image_and_tag: str) -> tuple[str, str]:
     return image_name, tag
 
 
+# Tag qualifiers naming the base distro, dropped when a tag policy is applied
+_DISTRO_TAG_QUALIFIER = re.compile(
+    r"^(?:slim|alpine[\d.]*|bookworm|bullseye|buster|stretch|trixie|"
+    r"noble|jammy|focal|bionic|ubi\d*|minimal)$"
+)
+
+
+def _apply_tag_policy(original_tag: str, tag_policy: str) -> tuple[str, str | None]:
+    """Derive the tag to match from the original tag according to a tag policy.
+
+    Distro qualifiers are dropped before the policy is applied; other qualifiers (such as
+    a JDK version) are kept so that tag matching can still use them. Tags without a
+    version fall back to 'latest'.
+
+    Examples:
+        ("18.19-bullseye", "major") -> ("18", None)
+        ("18.19-bullseye", "major.minor") -> ("18.19", None)
+        ("3.8.6-eclipse-temurin-17", "major.minor") -> ("3.8-eclipse-temurin-17", None)
+        ("bullseye-slim", "major") -> ("latest", "<warning>")
+        ("latest", "major") -> ("latest", None)
+        ("18.19-bullseye", "preserve") -> ("18.19-bullseye", None)
+
+    Returns:
+        Tuple of (tag to match, warning if the policy could not be applied).
+    """
+    if tag_policy == "preserve":
+        return original_tag, None
+    if tag_policy == "latest" or original_tag == "latest":
+        return "latest", None
+
+    match = re.match(r"^v?(\d+)(?:\.(\d+))?(?:\.\d+)*", original_tag)
+    if match is None:
+        return "latest", (
+            f"Tag '{original_tag}' has no version to apply tag policy '{tag_policy}' to - "
+            "using 'latest'."
+        )
+
+    major, minor = match.groups()
+    version = f"{major}.{minor}" if tag_policy == "major.minor" and minor is not None else major
+    qualifiers = [
+        q
+        for q in original_tag[match.end():].split("-")
+        if q and not _DISTRO_TAG_QUALIFIER.match(q.lower())
+    ]
+    return "-".join([version, *qualifiers]), None
+
+
+def _is_chainguard_reference(image_ref: str, org: str) -> bool:
+    """Check if an image reference already points at cgr.dev/{org} or the mirror registry.
+
+    References to other cgr.dev organizations (e.g., cgr.dev/chainguard/ when a private org is
+    selected) are not considered converted and get mapped to the selected org.
+    """
+    if image_ref.startswith(f"cgr.dev/{org}/"):
+        return True
+    mirror_registry = settings.mirror_registry
+    if mirror_registry:
+        return image_ref.startswith(f"{mirror_registry}/")
+    return False
+
+
 def _generate_generic_guidance() -> str:
     """Generate guidance for generic base images."""
     return """This is a generic base image. Chainguard recommends using a workload-specific image instead.
@@ -164,6 +228,24 @@ async def _verify_image_exists(
         return False
 
 
+async def _resolve_digest(client: ChainctlClient, image_ref: str) -> str | None:
+    """Resolve an image reference to its digest, caching results for the session."""
+    cached = OrgSession.get_digest(image_ref)
+    if cached is not None:
+        return cached
+
+    try:
+        resolved = await client.resolve_tag(image_ref)
+    except ChainctlError:
+        return None
+
+    if not resolved.exists:
+        return None
+
+    OrgSession.set_digest(image_ref, resolved.digest)
+    return resolved.digest
+
+
 async def _build_runtime_recommendations(
     config: dict[str, Any],
     org: str,
@@ -267,6 +349,46 @@ async def find_equivalent_chainguard_image(
             "If not provided, available variants will be listed for selection."
         ),
     ] = None,
+    build_args: Annotated[
+        dict[str, str] | None,
+        Field(
+            description="ARG/ENV values in scope for this FROM line, used to resolve variable references "
+            "like '${BASE}' or 'python:${PY_VERSION:-3.12}' in source_image_and_tag. "
+            "Include ARG defaults declared before the FROM (e.g., {'BASE': 'python:3.11-slim'})."
+        ),
+    ] = None,
+    stage_name: Annotated[
+        str | None,
+        Field(
+            description="Name of the Dockerfile stage this image is used in (from 'FROM ... AS <name>'). "
+            "Used to apply per-stage image overrides."
+        ),
+    ] = None,
+    tag_policy: Annotated[
+        Literal["preserve", "latest", "major", "major.minor"],
+        Field(
+            description="How the original tag's version carries into the Chainguard tag: 'preserve' "
+            "(match the full original tag), 'latest', 'major' (node:18.19-bullseye -> 18) or "
+            "'major.minor' (-> 18.19). Distro suffixes are dropped, while "
+            "qualifiers such as a JDK version (3.8-eclipse-temurin-17) are kept."
+        ),
+    ] = "preserve",
+    pin_digest: Annotated[
+        bool,
+        Field(
+            description="Resolve the matched image:tag to its digest and return pinned_image_ref "
+            "(image:tag@sha256:...) for supply-chain policies that require digest-pinned FROM lines. "
+            "For multi-arch tags this is the index digest, so it stays valid with FROM --platform."
+        ),
+    ] = False,
+    suggest_multistage: Annotated[
+        bool,
+        Field(
+            description="For interpreted runtimes (python, node) matched to a -dev tag, suggest splitting "
+            "a single-stage Dockerfile into a -dev builder stage and a distroless runtime stage, so "
+            "compilers and package managers stay out of the production image."
+        ),
+    ] = False,
 ) -> ChainguardImageResult:
     """Find Chainguard image equivalents for a source image.
 
@@ -275,7 +397,65 @@ async def find_equivalent_chainguard_image(
 
     If no organization is selected, returns available organizations for user selection.
     If no variant is selected, returns available variants with their capabilities.
+    Variable references in the source image are resolved from build_args first.
     """
+    # Step 0: Resolve ARG/ENV references (e.g., FROM ${BASE})
+    parameterized_source: str | None = None
+    if has_variables(source_image_and_tag):
+        resolved, unresolved = expand_build_args(source_image_and_tag, build_args or {})
+        if unresolved:
+            return ChainguardImageResult(
+                found=False,
+                source_image=source_image_and_tag,
+                message=f"Source image '{source_image_and_tag}' references unresolved variables: "
+                f"{', '.join(unresolved)}. Call this tool again with build_args set to their values "
+                "(the ARG defaults declared before this FROM).",
+            )
+        parameterized_source = source_image_and_tag
+        source_image_and_tag = resolved
+
+    # Step 0b: User-defined overrides take precedence over the builtin mappings
+    try:
+        override = lookup_image_override(source_image_and_tag, stage_name)
+    except ImageOverridesError as e:
+        return ChainguardImageResult(
+            found=False,
+            source_image=source_image_and_tag,
+            message=str(e),
+        )
+    if override is not None:
+        target_repo = override.target_image.split("@")[0]
+        if ":" in target_repo.rsplit("/", 1)[-1]:
+            target_repo = target_repo.rsplit(":", 1)[0]
+        override_message = (
+            f"Image override {override.rule} from {override.source_file} matched "
+            f"'{source_image_and_tag}'. Use {override.target_image} for this FROM (and any "
+            "COPY --from referencing the same image)."
+        )
+        # Overrides name their target exactly, so tag and digest options don't apply
+        ignored_options = [
+            name
+            for name, applies in (
+                (f"tag_policy='{tag_policy}'", tag_policy != "preserve"),
+                ("pin_digest", pin_digest),
+            )
+            if applies
+        ]
+        if ignored_options:
+            override_message += (
+                f" Not applied to the override target: {', '.join(ignored_options)} - "
+                "pin or retag it in the overrides file instead."
+            )
+        return ChainguardImageResult(
+            found=True,
+            source_image=source_image_and_tag,
+            chainguard_image=target_repo,
+            full_image_ref=override.target_image,
+            override_rule=override.rule,
+            recommendation=f"Use {override.target_image}",
+            message=override_message,
+        )
+
     # Step 1: Handle organization selection
     if organization:
         # User provided an org - validate and store it
@@ -315,6 +495,9 @@ async def find_equivalent_chainguard_image(
                 # Auto-select if only one org available
                 if len(auth_status.organizations) == 1:
                     OrgSession.set_org(auth_status.organizations[0])
+                elif settings.org in auth_status.organizations:
+                    # Default org configured via DFC_SHAZAM_ORG
+                    OrgSession.set_org(settings.org)
                 else:
                     # Multiple orgs - prompt user to select
                     org_list = "\n".join(f"  - {org}" for org in auth_status.organizations)
@@ -335,6 +518,17 @@ async def find_equivalent_chainguard_image(
     org = OrgSession.get_org()
     assert org is not None  # We checked is_org_selected above
 
+    # Already-converted references must not be mapped again
+    if _is_chainguard_reference(source_image_and_tag, org):
+        return ChainguardImageResult(
+            found=True,
+            source_image=source_image_and_tag,
+            full_image_ref=source_image_and_tag,
+            recommendation=f"Keep {source_image_and_tag}",
+            message=f"'{source_image_and_tag}' is already a Chainguard image reference. "
+            "Leave this FROM (or COPY --from) unchanged.",
+        )
+
     # Determine if we're using the public registry
     is_public = org == PUBLIC_REGISTRY
 
@@ -456,7 +650,8 @@ async def find_equivalent_chainguard_image(
 
     # Step 6: Find best matching tag for the variant
     variant_lower = variant.lower()
-    best_tag, score = _find_best_tag(original_tag, tag_names, preferred_variant=variant_lower)
+    policy_tag, policy_warning = _apply_tag_policy(original_tag, tag_policy)
+    best_tag, score = _find_best_tag(policy_tag, tag_names, preferred_variant=variant_lower)
 
     if best_tag is None or score < 0.3:
         return ChainguardImageResult(
@@ -469,7 +664,7 @@ async def find_equivalent_chainguard_image(
             variant=variant_lower,
             available_variants=available_variants,
             variant_capabilities=variant_capabilities,
-            message=f"No suitable tag match found for '{original_tag}' with variant '{variant_lower}'. "
+            message=f"No suitable tag match found for '{policy_tag}' with variant '{variant_lower}'. "
             f"Available tags: {', '.join(tag_names[:10])}{'...' if len(tag_names) > 10 else ''}",
         )
 
@@ -477,7 +672,20 @@ async def find_equivalent_chainguard_image(
     full_image_ref = f"cgr.dev/{org}/{chainguard_name}:{best_tag}"
     matched_variant = _get_tag_variant(best_tag)
 
-    # Step 8: Check for build-only image and add runtime recommendations
+    # Step 8: Optionally pin the matched tag to its digest
+    pinned_image_ref: str | None = None
+    pin_warning: str | None = None
+    if pin_digest:
+        digest = await _resolve_digest(client, full_image_ref)
+        if digest:
+            pinned_image_ref = f"{full_image_ref}@{digest}"
+        else:
+            pin_warning = (
+                f"⚠️ Could not resolve a digest for {full_image_ref}. "
+                "Use the unpinned reference or pin it manually."
+            )
+
+    # Step 9: Check for build-only image and add runtime recommendations
     runtime_config = IMAGE_RUNTIME_CONFIG.get(chainguard_name)
     is_build_only = False
     runtime_recommendations: list[RuntimeRecommendation] = []
@@ -495,18 +703,66 @@ async def find_equivalent_chainguard_image(
             runtime_config, org, best_tag, client
         )
 
+    # Step 10: Optionally suggest a builder/runtime split for same-family images
+    multistage_suggestion: str | None = None
+    if suggest_multistage and runtime_config and runtime_config["type"] == "same_family":
+        if matched_variant == "dev":
+            runtime_tag = re.sub(r"-dev$", "", best_tag)
+            runtime_ref = f"cgr.dev/{org}/{chainguard_name}:{runtime_tag}"
+            if runtime_tag in tag_names:
+                runtime_recommendations.append(
+                    RuntimeRecommendation(
+                        image=chainguard_name,
+                        full_image_ref=runtime_ref,
+                        description=f"Distroless {chainguard_name} runtime for the final stage",
+                        is_default=True,
+                        verified=True,
+                    )
+                )
+                multistage_suggestion = (
+                    f"\n🎯 MULTI-STAGE BUILD SUGGESTED\n"
+                    f"If this is a single-stage Dockerfile that installs build dependencies and then runs "
+                    f"the app, split it:\n"
+                    f"  1. FROM {full_image_ref} AS builder - package installs, COPY of sources, dependency install/build\n"
+                    f"  2. FROM {runtime_ref} - COPY the built artifacts (venv, node_modules, dist) from builder, "
+                    "and carry over ENV, EXPOSE, USER, ENTRYPOINT and CMD\n"
+                    "If you cannot tell which artifacts the app needs at runtime, keep the single-stage "
+                    "build and tell the user why."
+                )
+            else:
+                multistage_suggestion = (
+                    f"⚠️ No distroless tag '{runtime_tag}' found for {chainguard_name}; "
+                    "keep the single-stage build."
+                )
+
     messages = []
     if public_warning:
         messages.append(public_warning.rstrip())
 
+    if policy_warning:
+        messages.append(f"⚠️ {policy_warning}")
+    elif policy_tag != original_tag:
+        messages.append(f"Tag policy '{tag_policy}': matching '{policy_tag}' (from '{original_tag}')")
+
     if score < 1.0:
-        messages.append(f"Matched '{original_tag}' to '{best_tag}' (confidence: {score:.0%})")
+        messages.append(f"Matched '{policy_tag}' to '{best_tag}' (confidence: {score:.0%})")
 
     if variant_lower != matched_variant:
         messages.append(
             f"Note: '{variant_lower}' variant was requested but '{best_tag}' was the best version match."
         )
 
+    if pin_warning:
+        messages.append(pin_warning)
+
+    mirror_registry = settings.mirror_registry
+    mirrored_image_ref = settings.mirror_image_ref(pinned_image_ref or full_image_ref, org)
+    if mirror_registry and mirrored_image_ref:
+        messages.append(
+            f"Mirror registry: use {mirrored_image_ref} in the Dockerfile (from DFC_SHAZAM_REGISTRY). "
+            f"Rewrite runtime image references the same way (cgr.dev/{org}/ → {mirror_registry}/)."
+        )
+
     # Add runtime guidance to messages for build-only images
     if is_build_only and runtime_recommendations:
         verified_recs = [r for r in runtime_recommendations if r.verified]
@@ -538,9 +794,19 @@ async def find_equivalent_chainguard_image(
                 f"Verify with get_migration_instructions_for_chainguard_image."
             )
 
+    if multistage_suggestion:
+        messages.append(multistage_suggestion)
+
     if multi_stage_guidance:
         messages.append(f"\n📦 Multi-stage tip: {multi_stage_guidance}")
 
+    if parameterized_source:
+        messages.append(
+            f"\n🔧 PARAMETERIZED FROM: '{parameterized_source}' resolved to '{source_image_and_tag}'. "
+            f"Keep the FROM line as-is and update the ARG default to {full_image_ref} "
+            "so the Dockerfile stays parameterizable."
+        )
+
     messages.append(
         f"\n⚠️ NEXT STEP: Call get_migration_instructions_for_chainguard_image "
         f"with image_reference=\"{full_image_ref}\" to retrieve "
@@ -555,6 +821,8 @@ async def find_equivalent_chainguard_image(
         original_tag=original_tag,
         matched_tag=best_tag,
         full_image_ref=full_image_ref,
+        pinned_image_ref=pinned_image_ref,
+        mirrored_image_ref=mirrored_image_ref,
         variant=matched_variant,
         is_generic_base=False,
         available_variants=available_variants,
@@ -562,6 +830,6 @@ async def find_equivalent_chainguard_image(
         is_build_only=is_build_only,
         runtime_recommendations=runtime_recommendations,
         multi_stage_guidance=multi_stage_guidance,
-        recommendation=f"Use {full_image_ref}",
+        recommendation=f"Use {mirrored_image_ref or pinned_image_ref or full_image_ref}",
         message=" ".join(messages) if messages else None,
     )
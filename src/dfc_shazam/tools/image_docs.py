@@ -18,6 +18,7 @@ from dfc_shazam.models import (
     LinkedDocContent,
     MigrationInstructionsResult,
 )
+from dfc_shazam.tools.map_package import mappings_digest
 
 # Version for user agent - should match pyproject.toml
 VERSION = "0.1.0"
@@ -36,23 +37,103 @@ MAX_DOC_CONTENT_CHARS = 10000
 # Maximum lines for filesystem tree
 MAX_FILESYSTEM_TREE_LINES = 50
 
-# Static conversion tips returned with every get_image_overview call
+# Conversion tips returned for every image
 CONVERSION_TIPS = [
     "Review any `curl | sh` or `wget` commands that download and install software - "
     "check if there's a Wolfi APK package available instead using find_equivalent_apk_packages. "
-    "Installing via apk is more secure and maintainable.",
-    "Replace `apt-get`, `yum`, or `dnf` package installs with `apk add --no-cache`. "
-    "Use map_package to find APK equivalents for packages.",
-    "Chainguard images run as non-root by default. Add `USER root` before `apk add`, "
-    "then switch back with `USER nonroot` (or the image-specific user).",
+    "Installing via apk is more secure and maintainable. Well-known installers have APK "
+    "replacements: NodeSource setup (nodejs), get.docker.com (docker), rustup (rust), the pnpm "
+    "installer (pnpm), the AWS CLI installer (aws-cli), get-helm-3 (helm), and kubectl downloads "
+    "(kubectl). Replace only the `curl ... | sh` segment and keep the rest of the `&&` chain.",
+    "`ADD https://.../<tool>_<version>_linux_amd64.tar.gz` plus a RUN extracting it into "
+    "/usr/local/bin can often become `apk add <tool>=~<version>` (kubectl, helm, jq, yq, ...). "
+    "Remove the paired extraction and cleanup steps; leave downloads with no APK package as-is.",
+    "Replace `apt-get`, `yum`, `dnf`, or `zypper` package installs (`zypper install`/`in`) with "
+    "`apk add --no-cache`. Use map_package to find APK equivalents for packages. Drop zypper's "
+    "`--non-interactive`/`-n` flags, and remove `zypper ar` repo additions (flag them for review).",
+    "Alpine Dockerfiles already use `apk add`, but Alpine and Wolfi package names can differ - map "
+    "them with find_equivalent_apk_packages (source_distro='apk'). Use `--no-cache` instead of `-U`/"
+    "`--update`. Keep `--virtual .build-deps` groups and their matching `apk del .build-deps` in sync "
+    "with any renamed members, and drop `--repository` edge/testing repos (flag those packages for review).",
+    "Remove package manager cleanup that no longer applies after switching to apk: "
+    "`rm -rf /var/lib/apt/lists/*`, `rm -rf /var/cache/apt`, `apt-get clean`, "
+    "`yum/dnf/microdnf clean all`, `yum makecache`, and `zypper refresh`/`zypper clean`. "
+    "`apk add --no-cache` leaves no cache behind. "
+    "Keep the `&&` chain valid - don't leave a dangling `&&` at the start or end of the RUN line.",
+    "Package installs can hide behind prefixes like `sudo`, `env FOO=bar`, or `VAR=value` (e.g., "
+    "`sudo DEBIAN_FRONTEND=noninteractive apt-get install -y tzdata`), including mid `&&` chain. "
+    "Convert them too: drop `sudo` (not in the image; use `USER root`), drop assignments that only "
+    "silence apt (`DEBIAN_FRONTEND`, `TZ`, `APT_KEY_DONT_WARN_ON_DANGEROUS_USAGE`), and keep any others.",
+    "Delete standalone `RUN apt-get update` (or `yum makecache`) lines, including ones with flags "
+    "like `--allow-releaseinfo-change` - `apk add --no-cache` fetches fresh indexes itself. If the "
+    "stage has no later install, drop the line and mention it to the user.",
+    "Wolfi idioms: replace `locales` + `locale-gen en_US.UTF-8` with `apk add glibc-locale-en` and "
+    "`ENV LANG=en_US.UTF-8`, and zoneinfo symlinks with `apk add tzdata` and `ENV TZ=<zone>`. CA "
+    "certificates are present already - keep `update-ca-certificates` only for custom certs.",
+    "Never carry apt-only flags into `apk add`. Drop `-t`/`--target-release <suite>` (backports are "
+    "not needed - Wolfi tracks current releases). `apt-get build-dep <pkg>` has no APK equivalent: "
+    "install `build-base` plus the package's -dev dependencies mapped with find_equivalent_apk_packages.",
+    "Keep a leading `# syntax=docker/dockerfile:1` line first. In `RUN --mount=...`, point apt/dnf "
+    "cache mounts (`/var/cache/apt`, `/var/lib/apt`, `/var/cache/dnf`) at `/var/cache/apk` and "
+    "keep `type=secret`/`type=ssh` mounts and `--network` flags as written.",
+    "Convert `ONBUILD RUN`/`ONBUILD COPY --from=<image>` like top-level instructions, keeping the "
+    "`ONBUILD` prefix. Flag heredocs inside ONBUILD for manual review.",
+    "If the user asks for fewer layers, merge a stage's installs into one sorted `apk add "
+    "--no-cache` on the first install line, only across COPY/ENV/ARG (never past a RUN that may use "
+    "the packages). Tell the user which lines were folded together.",
+    "Chainguard images run as non-root by default. Add `USER root` before `apk add` unless the stage "
+    "already runs as root there, then switch back to the previously active user (`USER nonroot` or "
+    "the image-specific user, or the user the Dockerfile set earlier). Skip the restore if the stage "
+    "already has its own USER directive right after the install.",
     "Every COPY/ADD command MUST include `--chown=nonroot:nonroot` (or appropriate user) "
     "to ensure files are accessible to the non-root runtime user.",
     "Paths like `/root` are not accessible to non-root users. Use the user's home "
     "directory (typically `/home/nonroot`) for application files.",
+    "`useradd`/`groupadd` are not available by default. Prefer the existing `nonroot` user; "
+    "if a dedicated user is required, use busybox `addgroup -S app && adduser -S -D -G app "
+    "-h /home/app -s /sbin/nologin app` (`-r`→`-S`, `-d`→`-h`, `-g`→`-G`, `-M`→`-H`, `-u` unchanged; "
+    "`-D` avoids a password prompt), or `apk add shadow` to keep the original flags. "
+    "Flags with no busybox equivalent (e.g., `-K`, `--no-log-init`) must be removed.",
     "For distroless (non-dev) images: there is NO shell or package manager. Use multi-stage "
     "builds to install dependencies in a -dev stage, then COPY artifacts to the final image.",
 ]
 
+# Ecosystem-specific tips, added to CONVERSION_TIPS only for images of the matching family
+ECOSYSTEM_CONVERSION_TIPS: dict[str, list[str]] = {
+    "python": [
+        "`pip install` (also `pip3` and `python -m pip`) fails as nonroot because it cannot write "
+        "to system site-packages. Create a venv first - `RUN python -m venv /home/nonroot/venv` "
+        "with `ENV PATH=\"/home/nonroot/venv/bin:$PATH\"` - or use `pip install --user`, and drop "
+        "any `sudo` prefix. Builder stages that switch to `USER root` can keep their pip commands.",
+    ],
+    "node": [
+        "Global installs (`npm install -g`, `yarn global add`, `corepack enable/prepare`) fail as "
+        "nonroot because the global prefix is not writable. Prefer APK packages where they exist "
+        "(`pnpm`, `yarn`, `typescript`; `build-base` and `python3` for node-gyp), otherwise run "
+        "`npm config set prefix /home/nonroot/.npm-global` and add its bin directory to PATH. "
+        "Leave local `npm install`/`npm ci` unchanged.",
+    ],
+    "php": [
+        "`docker-php-ext-install` and `pecl install` are not available - extensions ship as APK "
+        "packages named after the PHP version (e.g., `gd`, `redis` -> `php-8.3-gd`, "
+        "`php-8.3-redis`). Find the exact names with find_equivalent_apk_packages.",
+    ],
+    "ruby": [
+        "Gems with native extensions need `build-base` plus the matching -dev libraries in the "
+        "build stage (e.g., `libxml2-dev` and `libxslt-dev` for nokogiri). `bundle config "
+        "build.<gem> ...` lines can stay once those libraries are installed.",
+    ],
+}
+
+
+def _get_conversion_tips(image_name: str) -> list[str]:
+    """Get the conversion tips for an image, including those for its ecosystem.
+
+    The family is the image name up to the first hyphen (e.g., 'python-fips' -> 'python').
+    """
+    family = image_name.split("-")[0]
+    return CONVERSION_TIPS + ECOSYSTEM_CONVERSION_TIPS.get(family, [])
+
 
 def _is_docker_available() -> bool:
     """Check if Docker CLI is available on the system."""
@@ -523,7 +604,7 @@ async def get_image_overview(
                 image_name=image_name,
                 overview_url=overview_url,
                 user_guidance=user_guidance,
-                conversion_tips=CONVERSION_TIPS,
+                conversion_tips=_get_conversion_tips(image_name),
                 available_users=available_users,
                 filesystem_tree=filesystem_tree,
                 overview_text=overview_text,
@@ -656,6 +737,15 @@ def _generate_entrypoint_guidance(config: ImageConfig, image_reference: str) ->
     if config.user:
         lines.append(f"  User: {config.user}")
 
+    if config.exposed_ports:
+        lines.append(f"  Exposed ports: {', '.join(config.exposed_ports)}")
+
+    if config.volumes:
+        lines.append(f"  Volumes: {', '.join(config.volumes)}")
+
+    if config.stop_signal:
+        lines.append(f"  Stop signal: {config.stop_signal}")
+
     lines.append(f"  Shell available: {'Yes' if config.has_shell else 'No'}")
     lines.append(f"  Apk available: {'Yes' if config.has_apk else 'No'}")
 
@@ -676,9 +766,37 @@ def _generate_entrypoint_guidance(config: ImageConfig, image_reference: str) ->
     if not config.has_shell:
         lines.append("- This is a distroless image - shell-form commands will NOT work")
         lines.append("- Use exec form: CMD [\"executable\", \"arg1\"] not CMD \"executable arg1\"")
+        lines.append("- Rewrite shell-form CMD/ENTRYPOINT by splitting the command into a JSON array: "
+                     "CMD python app.py -> CMD [\"python\", \"app.py\"]")
+        lines.append("- Commands using shell features (pipes, &&, redirects, $VAR expansion) cannot be "
+                     "converted mechanically - move that logic into the application, or use a -dev/slim "
+                     "variant if a shell is genuinely required")
     else:
         lines.append("- Shell is available - both exec form and shell form commands will work")
 
+    # HEALTHCHECK commands commonly depend on tools that aren't in the image
+    if not config.has_shell:
+        lines.append("- HEALTHCHECK CMD using curl/wget/nc will fail (no shell or tools) - remove it, "
+                     "or use exec form with a binary shipped in the image or copied from a builder stage")
+    elif config.has_apk:
+        lines.append("- HEALTHCHECK CMD using curl/wget/nc needs those packages - add them to the "
+                     "`apk add` in this stage")
+    else:
+        lines.append("- HEALTHCHECK CMD using curl may fail (no apk to install it) - busybox "
+                     "`wget -q --spider <url>` may work instead; keep HEALTHCHECK options unchanged")
+
+    # Non-root runtime users can't bind privileged ports or write to /root
+    if config.user and config.user.split(":")[0] not in ("0", "root"):
+        lines.append(f"- Runs as non-root user {config.user}: ports below 1024 cannot be bound. If the "
+                     "Dockerfile EXPOSEs a port like 80 or 443, switch to an unprivileged port (e.g., 8080) "
+                     "in EXPOSE and matching ENTRYPOINT/CMD/HEALTHCHECK port literals, or tell the user")
+        lines.append("- VOLUME paths under /root are not writable by this user - use a path under "
+                     "the user's home directory or the workdir instead")
+
+    if config.stop_signal:
+        lines.append(f"- Image sets STOPSIGNAL {config.stop_signal} - keep any STOPSIGNAL from the original "
+                     "Dockerfile only if the new entrypoint handles that signal")
+
     # General reminder
     lines.append("- IMPORTANT: Compare with your original image's entrypoint to ensure compatible behavior")
 
@@ -688,7 +806,8 @@ def _generate_entrypoint_guidance(config: ImageConfig, image_reference: str) ->
 async def _get_crane_config(image_reference: str) -> ImageConfig | None:
     """Get image configuration using crane config.
 
-    Returns ImageConfig with entrypoint, cmd, user, workdir, env, and shell/apk availability.
+    Returns ImageConfig with entrypoint, cmd, user, workdir, env, exposed ports, volumes,
+    stop signal, and shell/apk availability.
     Uses the cached probe_image_capabilities function to avoid duplicate crane export calls.
     """
     from dfc_shazam.tools.lookup_tag import probe_image_capabilities
@@ -718,6 +837,9 @@ async def _get_crane_config(image_reference: str) -> ImageConfig | None:
         user = container_config.get("User")
         workdir = container_config.get("WorkingDir")
         env = container_config.get("Env", [])
+        exposed_ports = sorted(container_config.get("ExposedPorts") or {})
+        volumes = sorted(container_config.get("Volumes") or {})
+        stop_signal = container_config.get("StopSignal")
 
         # Use cached probing function for shell/apk availability
         has_shell = False
@@ -732,6 +854,9 @@ async def _get_crane_config(image_reference: str) -> ImageConfig | None:
             user=user,
             workdir=workdir,
             env=env,
+            exposed_ports=exposed_ports,
+            volumes=volumes,
+            stop_signal=stop_signal,
             has_shell=has_shell,
             has_apk=has_apk,
         )
@@ -740,6 +865,15 @@ async def _get_crane_config(image_reference: str) -> ImageConfig | None:
         return None
 
 
+def _generate_conversion_label() -> str:
+    """Generate a LABEL instruction recording the conversion, tool version, and mappings revision."""
+    return (
+        'LABEL dev.dfc-shazam.converted="true" '
+        f'dev.dfc-shazam.version="{VERSION}" '
+        f'dev.dfc-shazam.mappings="sha256:{mappings_digest()}"'
+    )
+
+
 async def get_migration_instructions_for_chainguard_image(
     image_reference: Annotated[
         str,
@@ -747,6 +881,13 @@ async def get_migration_instructions_for_chainguard_image(
             description="Full Chainguard image reference (e.g., 'cgr.dev/{org}/python:3.12', 'cgr.dev/{org}/node:22-slim')"
         ),
     ],
+    add_conversion_label: Annotated[
+        bool,
+        Field(
+            description="Also return a LABEL instruction recording that the Dockerfile was converted, "
+            "with the dfc-shazam version and package mappings revision, for the final stage."
+        ),
+    ] = False,
 ) -> MigrationInstructionsResult:
     """Get migration instructions for a Chainguard image.
 
@@ -888,9 +1029,10 @@ async def get_migration_instructions_for_chainguard_image(
         image_name=image_name,
         overview_url=overview_url,
         user_guidance=user_guidance,
-        conversion_tips=CONVERSION_TIPS,
+        conversion_tips=_get_conversion_tips(image_name),
         available_users=available_users,
         filesystem_tree=filesystem_tree,
         overview_text=overview_text,
         best_practices=best_practices,
+        conversion_label=_generate_conversion_label() if add_conversion_label else None,
     )
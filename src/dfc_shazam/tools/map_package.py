@@ -1,6 +1,8 @@
-"""Tool for mapping package names from apt/yum to APK."""
+"""Tool for mapping package names from apt/yum/zypper/Alpine apk to Wolfi APK."""
 
+import hashlib
 import re
+import weakref
 from pathlib import Path
 from typing import Annotated, Literal
 
@@ -8,8 +10,32 @@ import yaml
 from pydantic import Field
 
 from dfc_shazam.apk import WolfiAPKIndex
+from dfc_shazam.build_args import expand_build_args, has_variables, variable_references
+from dfc_shazam.mappings import detect_package_manager
 from dfc_shazam.models import PackageMatch, PackageMappingBatchResult, PackageMappingResult
 
+SourceDistro = Literal["apt", "yum", "dnf", "zypper", "apk", "auto"]
+
+# Distros that can be selected automatically from the source image
+_DETECTABLE_DISTROS: tuple[SourceDistro, ...] = ("apt", "yum", "dnf", "zypper", "apk")
+
+# How the source distro was selected in auto mode
+DetectionSource = Literal["source_image", "run_commands"]
+
+# Package manager commands recognized in RUN lines, mapped to the distro they imply
+_PACKAGE_MANAGER_COMMANDS: dict[str, SourceDistro] = {
+    "apt-get": "apt",
+    "apt": "apt",
+    "yum": "yum",
+    "dnf": "dnf",
+    "microdnf": "dnf",
+    "zypper": "zypper",
+    "apk": "apk",
+}
+_PACKAGE_MANAGER_PATTERN = re.compile(
+    r"(?<![\w./-])(" + "|".join(re.escape(c) for c in _PACKAGE_MANAGER_COMMANDS) + r")(?=\s)"
+)
+
 # Load builtin mappings from dfc (vendored from https://github.com/chainguard-dev/dfc)
 _MAPPINGS_FILE = Path(__file__).parent.parent / "builtin-mappings.yaml"
 _BUILTIN_MAPPINGS: dict | None = None
@@ -24,13 +50,49 @@ def _load_builtin_mappings() -> dict:
     return _BUILTIN_MAPPINGS
 
 
+def _detect_distro_from_commands(commands: list[str]) -> tuple[SourceDistro | None, list[str]]:
+    """Infer the source distro from the package managers used in RUN commands.
+
+    Returns (distro, package_managers_seen). The distro is None if no package manager
+    was found or if commands from different distros are mixed.
+    """
+    seen: list[str] = []
+    for command in commands:
+        for match in _PACKAGE_MANAGER_PATTERN.finditer(command):
+            if match.group(1) not in seen:
+                seen.append(match.group(1))
+
+    distros = {_PACKAGE_MANAGER_COMMANDS[c] for c in seen}
+    # yum and dnf share the Fedora mappings
+    if distros == {"yum", "dnf"}:
+        return "dnf", seen
+    if len(distros) == 1:
+        return distros.pop(), seen
+    return None, seen
+
+
+# Alpine to Wolfi renames live next to the dfc mappings but are maintained here
+_ALPINE_MAPPINGS_FILE = Path(__file__).parent.parent / "builtin-alpine-mappings.yaml"
+_ALPINE_MAPPINGS: dict | None = None
+
+
+def _load_alpine_mappings() -> dict:
+    """Load and cache the builtin Alpine package mappings."""
+    global _ALPINE_MAPPINGS
+    if _ALPINE_MAPPINGS is None:
+        with open(_ALPINE_MAPPINGS_FILE) as f:
+            _ALPINE_MAPPINGS = yaml.safe_load(f)
+    return _ALPINE_MAPPINGS
+
+
 def _lookup_builtin_mapping(package: str, source_distro: str) -> list[str] | None:
     """Look up a package in the builtin mappings.
 
     Returns a list of APK package names if found, None otherwise.
     """
     mappings = _load_builtin_mappings()
-    packages_section = mappings.get("packages", {})
+    packages_section = dict(mappings.get("packages", {}))
+    packages_section["alpine"] = _load_alpine_mappings().get("packages", {}).get("alpine", {})
 
     # Determine which distro mappings to check
     distros_to_check: list[str] = []
@@ -38,8 +100,13 @@ def _lookup_builtin_mapping(package: str, source_distro: str) -> list[str] | Non
         distros_to_check = ["debian"]
     elif source_distro in ("yum", "dnf"):
         distros_to_check = ["fedora"]
+    elif source_distro == "zypper":
+        # dfc has no SUSE mappings, but SUSE shares most RPM package names with Fedora
+        distros_to_check = ["fedora"]
+    elif source_distro == "apk":
+        distros_to_check = ["alpine"]
     else:  # auto - check all
-        distros_to_check = ["debian", "fedora"]
+        distros_to_check = ["debian", "fedora", "alpine"]
 
     for distro in distros_to_check:
         distro_mappings = packages_section.get(distro, {})
@@ -53,6 +120,229 @@ def _lookup_builtin_mapping(package: str, source_distro: str) -> list[str] | Non
     return None  # Not found in builtin mappings
 
 
+# yum/dnf group mappings live next to the dfc mappings but are maintained here
+_GROUP_MAPPINGS_FILE = Path(__file__).parent.parent / "builtin-group-mappings.yaml"
+_GROUP_MAPPINGS: dict | None = None
+
+# dnf module stream syntax: "nodejs:18", "@nodejs:18", "@nodejs:18/common"
+_MODULE_STREAM_PATTERN = re.compile(
+    r"^@?([a-z0-9][a-z0-9_.+-]*):([a-z0-9][a-z0-9_.-]*)(?:/[a-z0-9_-]+)?$"
+)
+
+# Debian multiarch qualifiers look like module streams ("libc6:i386") but are not
+_APT_ARCH_QUALIFIERS = {
+    "all", "amd64", "any", "arm64", "armel", "armhf", "i386", "ppc64el", "s390x",
+}
+
+
+def _load_group_mappings() -> dict:
+    """Load and cache the builtin yum/dnf group mappings."""
+    global _GROUP_MAPPINGS
+    if _GROUP_MAPPINGS is None:
+        with open(_GROUP_MAPPINGS_FILE) as f:
+            _GROUP_MAPPINGS = yaml.safe_load(f)
+    return _GROUP_MAPPINGS
+
+
+def mappings_digest() -> str:
+    """Get a SHA-256 over the builtin package, Alpine and group mappings files."""
+    digest = hashlib.sha256()
+    for mappings_file in (_MAPPINGS_FILE, _ALPINE_MAPPINGS_FILE, _GROUP_MAPPINGS_FILE):
+        digest.update(mappings_file.read_bytes())
+    return digest.hexdigest()
+
+
+def _normalize_group_name(name: str) -> str:
+    """Normalize a yum/dnf group name to its id form.
+
+    Examples:
+        "Development Tools" -> "development-tools"
+        "@development-tools" -> "development-tools"
+        "@^minimal-environment" -> "minimal-environment"
+    """
+    group_id = name.strip().removeprefix("@").removeprefix("^")
+    return re.sub(r"[\s_]+", "-", group_id.strip().lower())
+
+
+def _lookup_group_mapping(package: str) -> list[str] | None:
+    """Look up a yum/dnf group (e.g. from `dnf groupinstall`) in the group mappings.
+
+    Returns a list of APK package names if the group is known, None otherwise.
+    """
+    groups = _load_group_mappings().get("groups", {}).get("fedora", {})
+    group_id = _normalize_group_name(package)
+    if group_id in groups:
+        return groups[group_id] or []
+    return None
+
+
+def _map_group(package: str, source_distro: str) -> PackageMappingResult | None:
+    """Map a yum/dnf group to APK packages, or return None if it is not a known group."""
+    # Only treat unambiguous group spellings (@id, ^environment, or display names with
+    # spaces) as groups, so that plain package names like "base" or "core" go through the
+    # normal lookup whatever the source distro
+    is_group_syntax = package.startswith(("@", "^"))
+    if not is_group_syntax and " " not in package.strip():
+        return None
+
+    apk_packages = _lookup_group_mapping(package)
+    if apk_packages is None:
+        if is_group_syntax and ":" not in package:
+            return PackageMappingResult(
+                source_package=package,
+                source_distro=source_distro,
+                matches=[],
+                message=f"Unknown package group '{package}'. List its member packages with "
+                "`dnf group info` and map them individually.",
+            )
+        return None
+
+    if not apk_packages:
+        return PackageMappingResult(
+            source_package=package,
+            source_distro=source_distro,
+            matches=[],
+            mapping_source="group",
+            message=f"Package group '{package}' has no APK equivalent (can be safely removed).",
+        )
+
+    apk_list = " ".join(apk_packages)
+    return PackageMappingResult(
+        source_package=package,
+        source_distro=source_distro,
+        matches=[
+            PackageMatch(
+                apk_package=apk_pkg,
+                matched_name=apk_pkg,
+                score=1.0,
+                description=f"Builtin group mapping from {package}",
+            )
+            for apk_pkg in apk_packages
+        ],
+        best_match=apk_packages[0],
+        mapping_source="group",
+        message=f"Group mapping: {package} → {apk_list}. "
+        "Replace `dnf groupinstall` with `apk add --no-cache` for these packages.",
+    )
+
+
+def _map_module_stream(
+    package: str,
+    source_distro: str,
+    index: WolfiAPKIndex,
+) -> PackageMappingResult | None:
+    """Map a dnf module stream (e.g. from `dnf module enable nodejs:18`) to an APK package.
+
+    Wolfi ships versioned packages instead of module streams, so "nodejs:18" maps to
+    "nodejs-18" when that package exists. Returns None if the name is not a module stream.
+    """
+    match = _MODULE_STREAM_PATTERN.match(package.lower().strip())
+    if match is None or match.group(2) in _APT_ARCH_QUALIFIERS:
+        return None
+
+    module, stream = match.groups()
+    note = (
+        f"dnf module stream '{package}' has no APK equivalent. Drop `dnf module enable` lines "
+        "and install the versioned package with `apk add --no-cache` instead."
+    )
+
+    versioned = index.get_package(f"{module}-{stream}")
+    if versioned is not None:
+        return PackageMappingResult(
+            source_package=package,
+            source_distro=source_distro,
+            matches=[
+                PackageMatch(
+                    apk_package=versioned.name,
+                    matched_name=versioned.name,
+                    score=1.0,
+                    description=versioned.description,
+                )
+            ],
+            best_match=versioned.name,
+            mapping_source="module-stream",
+            message=f"Module stream: {package} → {versioned.name}. {note}",
+        )
+
+    result = _map_single_package(module, source_distro, index)
+    result.source_package = package
+    result.message = f"{result.message} {note}" if result.message else note
+    return result
+
+
+# apt pin: "curl=7.88.1-10+deb12u5", optionally with an epoch ("libfoo=1:2.3-1").
+# Alpine apk pins use the same syntax ("curl=8.5.0-r0").
+_APT_PIN_PATTERN = re.compile(r"^(?P<name>[a-z0-9][a-z0-9+.:-]*)=(?P<version>\S+)$")
+
+# yum/dnf/zypper pin: name-version-release with optional epoch and arch ("curl-7.76.1-26.el9.x86_64")
+_RPM_PIN_PATTERN = re.compile(
+    r"^(?P<name>[a-zA-Z0-9][\w.+-]*?)-(?:\d+:)?(?P<version>\d[\w.+~^]*)-(?P<release>\d[\w.+~^]*?)"
+    r"(?P<arch>\.(?:x86_64|aarch64|noarch|i686|ppc64le|s390x))?$"
+)
+
+# Dist tag in an RPM release ("26.el9", "1.fc39", "150400.5.41.1.suse"). Without a dist tag
+# or arch suffix, names like "libgtk-3-0" are package names, not name-version-release pins.
+_RPM_DIST_TAG_PATTERN = re.compile(
+    r"\.(?:el\d+|fc\d+|amzn\d+|ol\d+|module[+_]el\d+|sle\w*|suse\w*|lp\d+)(?:[._+]|$)"
+)
+
+
+# apt release selector: "foo/bookworm-backports" (same effect as `-t bookworm-backports`)
+_APT_RELEASE_PATTERN = re.compile(r"^(?P<name>[a-z0-9][a-z0-9+.:-]*)/(?P<release>[a-z0-9][a-z0-9.-]*)$")
+
+
+def _split_release_selector(package: str, source_distro: str) -> tuple[str, str | None]:
+    """Split an apt release selector from a package name.
+
+    Examples:
+        "foo/bookworm-backports" -> ("foo", "bookworm-backports")
+        "foo" -> ("foo", None)
+    """
+    match = _APT_RELEASE_PATTERN.match(package.strip())
+    if match and source_distro in ("apt", "auto"):
+        return match.group("name"), match.group("release")
+    return package, None
+
+
+def _split_version_pin(package: str, source_distro: str) -> tuple[str, str | None, str | None]:
+    """Split a version pin from a package name.
+
+    Returns (name, pinned_version, upstream_version). The upstream version has the
+    epoch and distro revision removed, since those have no meaning in APK.
+
+    Examples:
+        "curl=7.88.1-10+deb12u5" -> ("curl", "7.88.1-10+deb12u5", "7.88.1")
+        "curl-7.76.1-26.el9" -> ("curl", "7.76.1-26.el9", "7.76.1")
+        "libgtk-3-0" -> ("libgtk-3-0", None, None)
+        "curl" -> ("curl", None, None)
+
+    RPM pins are only recognized for yum/dnf/zypper, and only when the release has a
+    dist tag or arch suffix, so Debian soname packages are never split in auto mode.
+    """
+    package = package.strip()
+
+    apt_match = _APT_PIN_PATTERN.match(package)
+    if apt_match and source_distro in ("apt", "apk", "auto"):
+        pinned = apt_match.group("version")
+        upstream = re.sub(r"^\d+:", "", pinned)
+        if "-" in upstream:
+            upstream = upstream.rsplit("-", 1)[0]
+        upstream = re.split(r"[+~]", upstream)[0].rstrip("*.")
+        return apt_match.group("name"), pinned, upstream or None
+
+    rpm_match = _RPM_PIN_PATTERN.match(package)
+    if (
+        rpm_match
+        and source_distro in ("yum", "dnf", "zypper")
+        and (rpm_match.group("arch") or _RPM_DIST_TAG_PATTERN.search(rpm_match.group("release")))
+    ):
+        pinned = package[len(rpm_match.group("name")) + 1 :]
+        upstream = re.split(r"[+~^]", rpm_match.group("version"))[0]
+        return rpm_match.group("name"), pinned, upstream or None
+
+    return package, None, None
+
+
 def _levenshtein_distance(s1: str, s2: str) -> int:
     """Calculate the Levenshtein distance between two strings."""
     if len(s1) < len(s2):
@@ -109,8 +399,8 @@ def _normalize_package_name(package: str, source_distro: str) -> str:
     """
     name = package.lower().strip()
 
-    # YUM/DNF uses -devel, APK uses -dev
-    if source_distro in ("yum", "dnf", "auto"):
+    # YUM/DNF/zypper use -devel, APK uses -dev
+    if source_distro in ("yum", "dnf", "zypper", "auto"):
         name = name.replace("-devel", "-dev")
 
     return name
@@ -177,6 +467,63 @@ def _get_candidates(normalized: str, index: WolfiAPKIndex) -> list[tuple[str, st
     return candidates
 
 
+# Fuzzy search results per loaded index, keyed by normalized name. Weakly keyed so
+# entries are dropped when an expired index is replaced.
+_FUZZY_MATCH_CACHE: "weakref.WeakKeyDictionary[WolfiAPKIndex, dict[str, list[PackageMatch]]]" = (
+    weakref.WeakKeyDictionary()
+)
+
+
+def _fuzzy_matches(normalized: str, index: WolfiAPKIndex) -> list[PackageMatch]:
+    """Find the top fuzzy matches for a normalized package name.
+
+    Results are memoized per index, so repeated packages across calls are scored once.
+    """
+    index_cache = _FUZZY_MATCH_CACHE.setdefault(index, {})
+    cached = index_cache.get(normalized)
+    if cached is not None:
+        return list(cached)
+
+    # Get candidate packages using cheap operations first
+    candidates = _get_candidates(normalized, index)
+
+    # Score only the candidate packages (much faster than scoring all)
+    scored_matches: list[tuple[float, str, str]] = []  # (score, name, description)
+
+    for name, description in candidates:
+        score = _similarity_score(normalized, name)
+
+        # Boost score if description contains the query
+        if normalized in description.lower():
+            score = min(1.0, score + 0.1)
+
+        if score >= 0.5:  # Threshold for fuzzy matches
+            scored_matches.append((score, name, description))
+
+    # Sort by score descending
+    scored_matches.sort(key=lambda x: x[0], reverse=True)
+
+    # Deduplicate by package name (keep highest score)
+    seen: set[str] = set()
+    matches: list[PackageMatch] = []
+    for score, name, description in scored_matches:
+        if name not in seen:
+            seen.add(name)
+            matches.append(
+                PackageMatch(
+                    apk_package=name,
+                    matched_name=name,
+                    score=score,
+                    description=description,
+                )
+            )
+        if len(matches) >= 5:
+            break
+
+    index_cache[normalized] = matches
+    return list(matches)
+
+
 def _map_single_package(
     package: str,
     source_distro: str,
@@ -187,6 +534,16 @@ def _map_single_package(
     Internal function used by map_package for batch processing.
     First checks builtin mappings from dfc, then falls back to fuzzy search.
     """
+    # yum/dnf groups and module streams are not package names - handle them first
+    if source_distro in ("yum", "dnf", "auto"):
+        group_result = _map_group(package, source_distro)
+        if group_result is not None:
+            return group_result
+
+        module_result = _map_module_stream(package, source_distro, index)
+        if module_result is not None:
+            return module_result
+
     # First, check builtin mappings from dfc
     builtin_result = _lookup_builtin_mapping(package, source_distro)
     if builtin_result is not None:
@@ -195,6 +552,7 @@ def _map_single_package(
                 source_package=package,
                 source_distro=source_distro,
                 matches=[],
+                mapping_source="builtin",
                 message=f"Package '{package}' has no APK equivalent (can be safely removed).",
             )
         # Found in builtin mappings - return all mapped packages
@@ -213,47 +571,13 @@ def _map_single_package(
             source_distro=source_distro,
             matches=matches,
             best_match=builtin_result[0],
+            mapping_source="builtin",
             message=f"Builtin mapping: {package} → {apk_list}",
         )
 
     # Fall back to fuzzy search against APK index
     normalized = _normalize_package_name(package, source_distro)
-
-    # Get candidate packages using cheap operations first
-    candidates = _get_candidates(normalized, index)
-
-    # Score only the candidate packages (much faster than scoring all)
-    scored_matches: list[tuple[float, str, str]] = []  # (score, name, description)
-
-    for name, description in candidates:
-        score = _similarity_score(normalized, name)
-
-        # Boost score if description contains the query
-        if normalized in description.lower():
-            score = min(1.0, score + 0.1)
-
-        if score >= 0.5:  # Threshold for fuzzy matches
-            scored_matches.append((score, name, description))
-
-    # Sort by score descending
-    scored_matches.sort(key=lambda x: x[0], reverse=True)
-
-    # Deduplicate by package name (keep highest score)
-    seen: set[str] = set()
-    matches: list[PackageMatch] = []
-    for score, name, description in scored_matches:
-        if name not in seen:
-            seen.add(name)
-            matches.append(
-                PackageMatch(
-                    apk_package=name,
-                    matched_name=name,
-                    score=score,
-                    description=description,
-                )
-            )
-        if len(matches) >= 5:
-            break
+    matches = _fuzzy_matches(normalized, index)
 
     if not matches:
         return PackageMappingResult(
@@ -280,30 +604,225 @@ def _map_single_package(
         source_distro=source_distro,
         matches=matches,
         best_match=best.apk_package,
+        mapping_source="index",
         message=message,
     )
 
 
+def _is_known_package(package: str, source_distro: str, index: WolfiAPKIndex) -> bool:
+    """Check if a name is a builtin mapping or an exact APK index match as written."""
+    return (
+        _lookup_builtin_mapping(package, source_distro) is not None
+        or index.get_package(package.lower()) is not None
+    )
+
+
+def _map_qualified_package(
+    package: str,
+    source_distro: str,
+    index: WolfiAPKIndex,
+    keep_version_pins: bool,
+) -> PackageMappingResult:
+    """Map a package that may carry a release selector or version pin.
+
+    Release selectors are dropped and version pins are translated to an APK constraint.
+    """
+    unqualified, release = _split_release_selector(package, source_distro)
+    name, pinned_version, upstream_version = _split_version_pin(unqualified, source_distro)
+    # A known package name that merely looks like a pin is not a pin
+    if pinned_version is not None and _is_known_package(unqualified, source_distro, index):
+        name, pinned_version, upstream_version = unqualified, None, None
+    if release is None and pinned_version is None:
+        return _map_single_package(package, source_distro, index)
+
+    result = _map_single_package(name, source_distro, index)
+    result.source_package = package
+
+    if release is not None:
+        note = (
+            f"Release selector '/{release}' dropped - Wolfi tracks current upstream releases, "
+            "so backports are not needed."
+        )
+        result.message = f"{result.message} {note}" if result.message else note
+
+    if pinned_version is None:
+        return result
+
+    result.pinned_version = pinned_version
+    if not result.best_match:
+        return result
+
+    if not keep_version_pins:
+        note = f"Version pin '{pinned_version}' dropped."
+    elif upstream_version is None:
+        note = f"Version pin '{pinned_version}' cannot be represented as an APK constraint; pin dropped."
+    else:
+        result.apk_constraint = f"{result.best_match}=~{upstream_version}"
+        note = (
+            f"Version pin '{pinned_version}' translated to '{result.apk_constraint}'. "
+            "Distro revisions have no APK equivalent and Wolfi only carries recent versions - "
+            "confirm the constraint resolves with validate_apk_packages_install."
+        )
+
+    result.message = f"{result.message} {note}" if result.message else note
+    return result
+
+
+def _map_parameterized_package(
+    package: str,
+    source_distro: str,
+    index: WolfiAPKIndex,
+    keep_version_pins: bool,
+    build_args: dict[str, str],
+) -> PackageMappingResult:
+    """Map a package name that references ARG/ENV variables (e.g. "python${PY_VERSION%.*}").
+
+    The name is expanded from build_args before mapping. If the mapped package still
+    contains a resolved value, the result suggests keeping the variable reference.
+    """
+    if not has_variables(package):
+        return _map_qualified_package(package, source_distro, index, keep_version_pins)
+
+    expanded, unresolved = expand_build_args(package, build_args)
+    if unresolved:
+        return PackageMappingResult(
+            source_package=package,
+            source_distro=source_distro,
+            matches=[],
+            message=f"'{package}' references unresolved variables: {', '.join(unresolved)}. "
+            "Pass their ARG/ENV values in build_args, or map this package manually.",
+        )
+
+    result = _map_qualified_package(expanded, source_distro, index, keep_version_pins)
+    result.source_package = package
+
+    note = f"Resolved '{package}' to '{expanded}'."
+    if result.best_match:
+        templated = result.apk_constraint or result.best_match
+        for reference in variable_references(package):
+            value, _ = expand_build_args(reference, build_args)
+            if value and value in templated:
+                templated = templated.replace(value, reference, 1)
+        if templated != (result.apk_constraint or result.best_match):
+            note += (
+                f" The mapping depends on the variable - write it as '{templated}' "
+                "to keep the Dockerfile parameterized."
+            )
+
+    result.message = f"{note} {result.message}" if result.message else note
+    return result
+
+
+# Minimum score accepted in strict mode (matches the "close match" threshold)
+_STRICT_MIN_SCORE = 0.9
+
+
+def _apply_strict_mode(result: PackageMappingResult) -> PackageMappingResult:
+    """Reject low-confidence fuzzy matches so they are reported as unmapped."""
+    if not result.best_match or not result.matches:
+        return result
+
+    best = result.matches[0]
+    if best.score >= _STRICT_MIN_SCORE:
+        return result
+
+    result.best_match = None
+    result.apk_constraint = None
+    result.mapping_source = None
+    note = (
+        f"Strict mode: rejected fuzzy match {best.apk_package} for '{result.source_package}' "
+        f"(score: {best.score:.0%}). Find the correct package manually or ask the user."
+    )
+    result.message = f"{result.message} {note}" if result.message else note
+    return result
+
+
 async def find_equivalent_apk_packages(
     packages: Annotated[
         list[str],
-        Field(description="List of source package names (e.g., ['libssl-dev', 'build-essential', 'curl'])"),
+        Field(
+            description="List of source package names (e.g., ['libssl-dev', 'build-essential', 'curl']). "
+            "yum/dnf groups ('Development Tools', '@development-tools') and dnf module streams "
+            "('nodejs:18') are also accepted."
+        ),
     ],
     source_distro: Annotated[
-        Literal["apt", "yum", "dnf", "auto"],
+        SourceDistro,
         Field(
-            description="Source distribution type: 'apt' (Debian/Ubuntu), 'yum'/'dnf' (RHEL/Fedora), or 'auto' to try both"
+            description="Source distribution type: 'apt' (Debian/Ubuntu), 'yum'/'dnf' (RHEL/Fedora), "
+            "'zypper' (openSUSE/SLES), 'apk' (Alpine), or 'auto' to try all"
         ),
     ] = "auto",
+    keep_version_pins: Annotated[
+        bool,
+        Field(
+            description="Translate version pins ('curl=7.88.1-10+deb12u5', 'curl-7.76.1-26.el9') into "
+            "APK constraints ('curl=~7.88.1'). Set to False to drop pins."
+        ),
+    ] = True,
+    strict: Annotated[
+        bool,
+        Field(
+            description="Only accept builtin, exact, or close matches. Low-confidence fuzzy matches "
+            "are reported as unmapped instead of being suggested."
+        ),
+    ] = False,
+    source_image: Annotated[
+        str | None,
+        Field(
+            description="Base image of the Dockerfile stage the packages are installed in "
+            "(e.g., 'ubuntu:22.04', 'python:3.12-slim-bookworm'). When source_distro is 'auto', "
+            "the distro is detected from it so the matching builtin mappings are used."
+        ),
+    ] = None,
+    run_commands: Annotated[
+        list[str] | None,
+        Field(
+            description="RUN commands from the same stage (e.g., ['apt-get update && apt-get install -y curl']). "
+            "When source_distro is 'auto' and the distro can't be detected from source_image, it is "
+            "inferred from the package managers these commands use."
+        ),
+    ] = None,
+    build_args: Annotated[
+        dict[str, str] | None,
+        Field(
+            description="ARG/ENV values declared in the Dockerfile "
+            "(e.g., {'PYTHON_VERSION': '3.11.8'}), used to expand package names such as 'python${PYTHON_VERSION%.*}' before mapping."
+        ),
+    ] = None,
 ) -> PackageMappingBatchResult:
-    """Map package names from apt/yum to their APK (Wolfi) equivalents.
+    """Map package names from apt/yum/zypper/Alpine apk to their APK (Wolfi) equivalents.
 
     Uses fuzzy matching against both the Wolfi APK package index and
     Chainguard extras repository to find the best matching package(s).
     Returns scored matches ordered by relevance.
 
     Accepts a list of packages to map in a single call for efficiency.
+    Version pins are carried through as APK constraints in apk_constraint.
+    Packages without a usable mapping are listed in unmapped.
     """
+    detection_note: str | None = None
+    detected_from: DetectionSource | None = None
+    if source_distro == "auto" and source_image:
+        detected = detect_package_manager(source_image)
+        distro = next((d for d in _DETECTABLE_DISTROS if d == detected), None)
+        if distro is not None:
+            source_distro = distro
+            detected_from = "source_image"
+            detection_note = f"Detected source distro '{distro}' from {source_image}"
+
+    if source_distro == "auto" and run_commands:
+        distro, seen = _detect_distro_from_commands(run_commands)
+        if distro is not None:
+            source_distro = distro
+            detected_from = "run_commands"
+            detection_note = f"Inferred source distro '{distro}' from RUN commands"
+        elif seen:
+            detection_note = (
+                f"⚠️ RUN commands use several package managers ({', '.join(seen)}); "
+                "set source_distro explicitly"
+            )
+
     try:
         index = await WolfiAPKIndex.load(arch="x86_64", include_extras=True)
     except Exception as e:
@@ -319,13 +838,20 @@ async def find_equivalent_apk_packages(
                 for pkg in packages
             ],
             summary=f"Failed to load APK index: {e}",
+            unmapped=list(packages),
         )
 
     # Map each package
-    results = [_map_single_package(pkg, source_distro, index) for pkg in packages]
+    results = [
+        _map_parameterized_package(pkg, source_distro, index, keep_version_pins, build_args or {})
+        for pkg in packages
+    ]
+    if strict:
+        results = [_apply_strict_mode(result) for result in results]
 
     # Build summary
     mappings: list[str] = []
+    removable: list[str] = []
     unmapped: list[str] = []
 
     for result in results:
@@ -334,13 +860,20 @@ async def find_equivalent_apk_packages(
                 mappings.append(f"{result.source_package} → {result.best_match}")
             else:
                 mappings.append(result.source_package)
+        elif result.mapping_source is not None:
+            # Matched a rule that maps to nothing - the package can be dropped
+            removable.append(result.source_package)
         else:
             unmapped.append(result.source_package)
 
     summary_parts = []
+    if detection_note:
+        summary_parts.append(detection_note)
     if mappings:
-        apk_packages = [r.best_match for r in results if r.best_match]
+        apk_packages = [r.apk_constraint or r.best_match for r in results if r.best_match]
         summary_parts.append(f"APK packages: {' '.join(apk_packages)}")
+    if removable:
+        summary_parts.append(f"No APK equivalent needed (remove): {', '.join(removable)}")
     if unmapped:
         summary_parts.append(f"No matches found for: {', '.join(unmapped)}")
 
@@ -348,4 +881,6 @@ async def find_equivalent_apk_packages(
         source_distro=source_distro,
         results=results,
         summary="\n".join(summary_parts) if summary_parts else "No packages processed",
+        unmapped=unmapped,
+        distro_detected_from=detected_from,
     )
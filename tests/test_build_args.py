@@ -0,0 +1,77 @@
+"""Tests for Dockerfile ARG/ENV variable expansion."""
+
+import pytest
+
+from dfc_shazam.build_args import expand_build_args, has_variables, variable_references
+
+
+class TestHasVariables:
+    @pytest.mark.parametrize("value", ["${BASE}", "$BASE", "python:${PY:-3.12}", "$REGISTRY/node"])
+    def test_detects_references(self, value: str) -> None:
+        assert has_variables(value)
+
+    @pytest.mark.parametrize("value", ["python:3.12", "node:18-alpine", "curl"])
+    def test_plain_values(self, value: str) -> None:
+        assert not has_variables(value)
+
+
+class TestExpandBuildArgs:
+    def test_braced_variable(self) -> None:
+        assert expand_build_args("${BASE}", {"BASE": "python:3.11"}) == ("python:3.11", [])
+
+    def test_bare_variable(self) -> None:
+        assert expand_build_args("$BASE", {"BASE": "python:3.11"}) == ("python:3.11", [])
+
+    def test_default_when_unset(self) -> None:
+        assert expand_build_args("python:${PY:-3.12}-slim", {}) == ("python:3.12-slim", [])
+
+    def test_unresolved_variable(self) -> None:
+        assert expand_build_args("$REGISTRY/node:18", {}) == ("/node:18", ["REGISTRY"])
+
+    def test_unresolved_reported_once(self) -> None:
+        _, unresolved = expand_build_args("${A}-${A}-${B}", {})
+        assert unresolved == ["A", "B"]
+
+    def test_colon_dash_uses_default_for_empty(self) -> None:
+        assert expand_build_args("${PY:-3.12}", {"PY": ""}) == ("3.12", [])
+
+    def test_dash_keeps_empty_value(self) -> None:
+        assert expand_build_args("${PY-3.12}", {"PY": ""}) == ("", [])
+
+    def test_dash_uses_default_when_unset(self) -> None:
+        assert expand_build_args("${PY-3.12}", {}) == ("3.12", [])
+
+    def test_set_value_wins_over_default(self) -> None:
+        assert expand_build_args("${PY:-3.12}", {"PY": "3.11"}) == ("3.11", [])
+
+    def test_colon_plus_ignores_empty(self) -> None:
+        assert expand_build_args("${PY:+set}", {"PY": ""}) == ("", [])
+
+    def test_plus_substitutes_for_empty(self) -> None:
+        assert expand_build_args("${PY+set}", {"PY": ""}) == ("set", [])
+
+    def test_plus_unset(self) -> None:
+        assert expand_build_args("${PY+set}", {}) == ("", [])
+
+    def test_shortest_suffix_removal(self) -> None:
+        assert expand_build_args("python${PY%.*}", {"PY": "3.11.8"}) == ("python3.11", [])
+
+    def test_longest_suffix_removal(self) -> None:
+        assert expand_build_args("${PY%%.*}", {"PY": "3.11.8"}) == ("3", [])
+
+    def test_shortest_prefix_removal(self) -> None:
+        assert expand_build_args("${PY#*.}", {"PY": "3.11.8"}) == ("11.8", [])
+
+    def test_longest_prefix_removal(self) -> None:
+        assert expand_build_args("${PY##*.}", {"PY": "3.11.8"}) == ("8", [])
+
+    def test_non_matching_pattern_keeps_value(self) -> None:
+        assert expand_build_args("${PY%-*}", {"PY": "3.11.8"}) == ("3.11.8", [])
+
+    def test_pattern_removal_on_unset_variable(self) -> None:
+        assert expand_build_args("python${PY%.*}", {}) == ("python", ["PY"])
+
+
+class TestVariableReferences:
+    def test_lists_references_as_written(self) -> None:
+        assert variable_references("python${PY%.*}-$SUFFIX") == ["${PY%.*}", "$SUFFIX"]
@@ -0,0 +1,77 @@
+"""Tests for the pure helpers in the Chainguard image lookup tool."""
+
+import pytest
+
+from dfc_shazam.tools import find_equiv_cgr_image
+from dfc_shazam.tools.find_equiv_cgr_image import _apply_tag_policy, _is_chainguard_reference
+from dfc_shazam.tools.lookup_tag import _find_best_tag
+
+
+class TestApplyTagPolicy:
+    @pytest.mark.parametrize(
+        "original_tag, tag_policy, expected",
+        [
+            ("18.19-bullseye", "major", "18"),
+            ("18.19-bullseye", "major.minor", "18.19"),
+            ("18.19.0-alpine3.19", "major.minor", "18.19"),
+            ("3.12.1-slim-bookworm", "major.minor", "3.12"),
+            ("v1.22.3", "major", "1"),
+            ("18", "major.minor", "18"),
+            ("3.8-eclipse-temurin-17", "major.minor", "3.8-eclipse-temurin-17"),
+            ("3.8.6-eclipse-temurin-17-alpine", "major", "3-eclipse-temurin-17"),
+            ("18.19-bullseye", "preserve", "18.19-bullseye"),
+            ("18.19-bullseye", "latest", "latest"),
+            ("latest", "major", "latest"),
+            ("latest", "major.minor", "latest"),
+        ],
+    )
+    def test_policies(self, original_tag: str, tag_policy: str, expected: str) -> None:
+        assert _apply_tag_policy(original_tag, tag_policy) == (expected, None)
+
+    def test_tag_without_version_warns(self) -> None:
+        tag, warning = _apply_tag_policy("bullseye-slim", "major")
+        assert tag == "latest"
+        assert warning is not None and "bullseye-slim" in warning
+
+    @pytest.mark.parametrize("tag_policy", ["major", "major.minor"])
+    def test_jdk_qualifier_still_selects_matching_tag(self, tag_policy: str) -> None:
+        policy_tag, _ = _apply_tag_policy("3.8-eclipse-temurin-17", tag_policy)
+        best_tag, _ = _find_best_tag(
+            policy_tag, ["3.8-jdk11-dev", "3.8-jdk17-dev"], preferred_variant="dev"
+        )
+        assert best_tag == "3.8-jdk17-dev"
+
+
+class TestIsChainguardReference:
+    @pytest.fixture(autouse=True)
+    def no_mirror(self, monkeypatch: pytest.MonkeyPatch) -> None:
+        monkeypatch.setattr(find_equiv_cgr_image.settings, "registry", None)
+
+    @pytest.mark.parametrize(
+        "image_ref", ["cgr.dev/my-org/python:3.12-dev", "cgr.dev/my-org/python:latest@sha256:abc"]
+    )
+    def test_selected_org_is_converted(self, image_ref: str) -> None:
+        assert _is_chainguard_reference(image_ref, "my-org")
+
+    def test_public_registry_when_selected(self) -> None:
+        assert _is_chainguard_reference("cgr.dev/chainguard/python:latest", "chainguard")
+
+    @pytest.mark.parametrize(
+        "image_ref", ["cgr.dev/chainguard/python:latest", "cgr.dev/other-org/python:3.12"]
+    )
+    def test_other_orgs_are_remapped(self, image_ref: str) -> None:
+        assert not _is_chainguard_reference(image_ref, "my-org")
+
+    def test_prefix_must_end_at_org(self) -> None:
+        assert not _is_chainguard_reference("cgr.dev/my-org-dev/python:3.12", "my-org")
+
+    @pytest.mark.parametrize("registry", ["mirror.example.com/cg", "mirror.example.com/cg/"])
+    def test_mirror_registry_is_converted(
+        self, monkeypatch: pytest.MonkeyPatch, registry: str
+    ) -> None:
+        monkeypatch.setattr(find_equiv_cgr_image.settings, "registry", registry)
+        assert _is_chainguard_reference("mirror.example.com/cg/python:3.12", "my-org")
+        assert not _is_chainguard_reference("mirror.example.com/other/python:3.12", "my-org")
+
+    def test_docker_hub_reference(self) -> None:
+        assert not _is_chainguard_reference("python:3.12", "my-org")
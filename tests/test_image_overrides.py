@@ -0,0 +1,111 @@
+"""Tests for user-defined image overrides."""
+
+from pathlib import Path
+
+import pytest
+
+from dfc_shazam.mappings import image_overrides
+from dfc_shazam.mappings.image_overrides import (
+    ImageOverridesError,
+    _candidate_references,
+    lookup_image_override,
+)
+
+
+@pytest.fixture
+def overrides_file(tmp_path: Path, monkeypatch: pytest.MonkeyPatch) -> Path:
+    path = tmp_path / "overrides.yaml"
+    path.write_text(
+        "images:\n"
+        '  "python:*": cgr.dev/my-org/python:3.12-dev\n'
+        '  "docker.io/library/node:18*": cgr.dev/my-org/node:18\n'
+        '  "docker.io/bitnami/*": cgr.dev/my-org/bitnami\n'
+        "stages:\n"
+        "  builder: cgr.dev/my-org/go:1.22-dev\n"
+    )
+    monkeypatch.setattr(image_overrides.settings, "image_overrides_file", str(path))
+    return path
+
+
+class TestCandidateReferences:
+    @pytest.mark.parametrize(
+        "source_image",
+        ["python:3.12", "library/python:3.12", "docker.io/library/python:3.12"],
+    )
+    def test_official_image_spellings_are_equivalent(self, source_image: str) -> None:
+        assert set(_candidate_references(source_image)) == {
+            "python:3.12",
+            "library/python:3.12",
+            "docker.io/library/python:3.12",
+        }
+
+    def test_namespaced_hub_image(self) -> None:
+        assert set(_candidate_references("docker.io/bitnami/redis:7")) == {
+            "bitnami/redis:7",
+            "docker.io/bitnami/redis:7",
+        }
+
+    @pytest.mark.parametrize("source_image", ["python", "python@sha256:abc"])
+    def test_missing_tag_is_latest(self, source_image: str) -> None:
+        assert set(_candidate_references(source_image)) == {
+            "python:latest",
+            "library/python:latest",
+            "docker.io/library/python:latest",
+        }
+
+    def test_digest_is_stripped(self) -> None:
+        assert _candidate_references("ghcr.io/org/app:1@sha256:abc") == ["ghcr.io/org/app:1"]
+
+    def test_registry_port_is_not_a_tag(self) -> None:
+        assert _candidate_references("localhost:5000/app") == ["localhost:5000/app:latest"]
+
+    def test_other_registries_are_not_expanded(self) -> None:
+        assert _candidate_references("ghcr.io/org/app:1") == ["ghcr.io/org/app:1"]
+
+
+class TestLookupImageOverride:
+    def test_no_overrides_file(self, monkeypatch: pytest.MonkeyPatch) -> None:
+        monkeypatch.setattr(image_overrides.settings, "image_overrides_file", None)
+        assert lookup_image_override("python:3.12") is None
+
+    @pytest.mark.parametrize(
+        "source_image",
+        ["python:3.12", "docker.io/library/python:3.12", "python", "python@sha256:abc"],
+    )
+    def test_short_pattern_matches_any_spelling(
+        self, overrides_file: Path, source_image: str
+    ) -> None:
+        override = lookup_image_override(source_image)
+        assert override is not None
+        assert override.target_image == "cgr.dev/my-org/python:3.12-dev"
+        assert override.rule == "images['python:*']"
+        assert override.source_file == str(overrides_file)
+
+    @pytest.mark.parametrize("source_image", ["node:18-alpine", "docker.io/library/node:18"])
+    def test_canonical_pattern_matches_any_spelling(
+        self, overrides_file: Path, source_image: str
+    ) -> None:
+        override = lookup_image_override(source_image)
+        assert override is not None
+        assert override.target_image == "cgr.dev/my-org/node:18"
+
+    def test_namespaced_pattern(self, overrides_file: Path) -> None:
+        override = lookup_image_override("bitnami/redis:7")
+        assert override is not None
+        assert override.rule == "images['docker.io/bitnami/*']"
+
+    def test_stage_wins_over_image_pattern(self, overrides_file: Path) -> None:
+        override = lookup_image_override("python:3.12", stage_name="Builder")
+        assert override is not None
+        assert override.target_image == "cgr.dev/my-org/go:1.22-dev"
+        assert override.rule == "stages['builder']"
+
+    def test_no_match(self, overrides_file: Path) -> None:
+        assert lookup_image_override("ghcr.io/org/python:3.12") is None
+
+    def test_malformed_file(self, tmp_path: Path, monkeypatch: pytest.MonkeyPatch) -> None:
+        path = tmp_path / "overrides.yaml"
+        path.write_text("images:\n  - python\n")
+        monkeypatch.setattr(image_overrides.settings, "image_overrides_file", str(path))
+        with pytest.raises(ImageOverridesError, match="must map strings"):
+            lookup_image_override("python:3.12")
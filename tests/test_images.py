@@ -0,0 +1,43 @@
+"""Tests for source image classification."""
+
+import pytest
+
+from dfc_shazam.mappings.images import detect_package_manager, is_generic_base_image
+
+
+class TestDetectPackageManager:
+    @pytest.mark.parametrize(
+        "source_image",
+        [
+            "ubuntu",
+            "ubuntu:22.04",
+            "docker.io/library/ubuntu:22.04",
+            "ubuntu@sha256:0123abcd",
+            "ubuntu:22.04@sha256:0123abcd",
+        ],
+    )
+    def test_generic_base_image(self, source_image: str) -> None:
+        assert detect_package_manager(source_image) == "apt"
+
+    @pytest.mark.parametrize(
+        "source_image, expected",
+        [
+            ("python:3.12-slim-bookworm", "apt"),
+            ("node:18-alpine", "apk"),
+            ("python:3.12-slim-bookworm@sha256:0123abcd", "apt"),
+        ],
+    )
+    def test_distro_tag_hints(self, source_image: str, expected: str) -> None:
+        assert detect_package_manager(source_image) == expected
+
+    def test_unknown(self) -> None:
+        assert detect_package_manager("python:3.12") is None
+
+
+class TestIsGenericBaseImage:
+    @pytest.mark.parametrize("source_image", ["ubuntu@sha256:0123abcd", "ubuntu:22.04"])
+    def test_generic(self, source_image: str) -> None:
+        assert is_generic_base_image(source_image)
+
+    def test_workload_image(self) -> None:
+        assert not is_generic_base_image("python:3.12")
@@ -0,0 +1,200 @@
+"""Tests for the pure parsing helpers in the package mapping tool."""
+
+import pytest
+
+from dfc_shazam.tools.map_package import (
+    _MODULE_STREAM_PATTERN,
+    _detect_distro_from_commands,
+    _lookup_builtin_mapping,
+    _map_group,
+    _normalize_group_name,
+    _split_version_pin,
+    mappings_digest,
+)
+
+
+class TestSplitVersionPin:
+    @pytest.mark.parametrize(
+        "package, source_distro, expected",
+        [
+            ("curl=7.88.1-10+deb12u5", "apt", ("curl", "7.88.1-10+deb12u5", "7.88.1")),
+            ("curl=7.88.1-10+deb12u5", "auto", ("curl", "7.88.1-10+deb12u5", "7.88.1")),
+            ("libfoo=1:2.3-1", "apt", ("libfoo", "1:2.3-1", "2.3")),
+            ("curl=8.5.0-r0", "apk", ("curl", "8.5.0-r0", "8.5.0")),
+        ],
+    )
+    def test_apt_style_pins(
+        self, package: str, source_distro: str, expected: tuple[str, str, str]
+    ) -> None:
+        assert _split_version_pin(package, source_distro) == expected
+
+    @pytest.mark.parametrize(
+        "package, source_distro, expected",
+        [
+            ("curl-7.76.1-26.el9", "dnf", ("curl", "7.76.1-26.el9", "7.76.1")),
+            ("curl-7.76.1-26.el9_3", "yum", ("curl", "7.76.1-26.el9_3", "7.76.1")),
+            ("python3-3.12.1-1.fc39", "dnf", ("python3", "3.12.1-1.fc39", "3.12.1")),
+            (
+                "curl-7.76.1-26.el9.x86_64",
+                "yum",
+                ("curl", "7.76.1-26.el9.x86_64", "7.76.1"),
+            ),
+            (
+                "curl-8.0.1-150400.5.41.1.x86_64",
+                "zypper",
+                ("curl", "8.0.1-150400.5.41.1.x86_64", "8.0.1"),
+            ),
+        ],
+    )
+    def test_rpm_pins(
+        self, package: str, source_distro: str, expected: tuple[str, str, str]
+    ) -> None:
+        assert _split_version_pin(package, source_distro) == expected
+
+    @pytest.mark.parametrize(
+        "package", ["libgtk-3-0", "libpcre2-8-0", "libgdk-pixbuf-2.0-0", "libsdl2-2.0-0"]
+    )
+    @pytest.mark.parametrize("source_distro", ["auto", "apt", "dnf"])
+    def test_soname_packages_are_not_pins(self, package: str, source_distro: str) -> None:
+        assert _split_version_pin(package, source_distro) == (package, None, None)
+
+    def test_rpm_pins_ignored_in_auto_mode(self) -> None:
+        assert _split_version_pin("curl-7.76.1-26.el9", "auto") == (
+            "curl-7.76.1-26.el9",
+            None,
+            None,
+        )
+
+    def test_unpinned_package(self) -> None:
+        assert _split_version_pin("curl", "apt") == ("curl", None, None)
+
+
+class TestDetectDistroFromCommands:
+    @pytest.mark.parametrize(
+        "command, expected",
+        [
+            ("apt-get update && apt-get install -y curl", ("apt", ["apt-get"])),
+            ("apt install -y curl", ("apt", ["apt"])),
+            ("yum install -y curl", ("yum", ["yum"])),
+            ("microdnf install -y curl", ("dnf", ["microdnf"])),
+            ("zypper --non-interactive install curl", ("zypper", ["zypper"])),
+            ("apk add --no-cache curl", ("apk", ["apk"])),
+        ],
+    )
+    def test_single_package_manager(self, command: str, expected: tuple[str, list[str]]) -> None:
+        assert _detect_distro_from_commands([command]) == expected
+
+    def test_yum_and_dnf_share_mappings(self) -> None:
+        assert _detect_distro_from_commands(["yum install -y curl", "dnf install -y git"]) == (
+            "dnf",
+            ["yum", "dnf"],
+        )
+
+    def test_mixed_distros(self) -> None:
+        assert _detect_distro_from_commands(["apt-get install -y curl", "apk add git"]) == (
+            None,
+            ["apt-get", "apk"],
+        )
+
+    def test_no_package_manager(self) -> None:
+        assert _detect_distro_from_commands(["make install", "echo done"]) == (None, [])
+
+    @pytest.mark.parametrize(
+        "command",
+        [
+            "/usr/local/bin/apt-helper download-file",
+            "./apk-tools/build.sh",
+            "cp snapd.apk /tmp",
+            "echo apt",
+        ],
+    )
+    def test_ignores_non_command_mentions(self, command: str) -> None:
+        assert _detect_distro_from_commands([command]) == (None, [])
+
+
+class TestLookupBuiltinMapping:
+    @pytest.mark.parametrize(
+        "package, expected",
+        [
+            ("libc-dev", ["glibc-dev"]),
+            ("musl-dev", ["glibc-dev"]),
+            ("alpine-sdk", ["build-base"]),
+            ("openjdk17", ["openjdk-17"]),
+            ("gcompat", []),
+        ],
+    )
+    def test_alpine_renames(self, package: str, expected: list[str]) -> None:
+        assert _lookup_builtin_mapping(package, "apk") == expected
+
+    def test_alpine_renames_in_auto_mode(self) -> None:
+        assert _lookup_builtin_mapping("alpine-sdk", "auto") == ["build-base"]
+
+    def test_alpine_renames_not_used_for_other_distros(self) -> None:
+        assert _lookup_builtin_mapping("alpine-sdk", "apt") is None
+
+    def test_same_name_packages_are_not_listed(self) -> None:
+        assert _lookup_builtin_mapping("curl", "apk") is None
+
+
+class TestNormalizeGroupName:
+    @pytest.mark.parametrize(
+        "name, expected",
+        [
+            ("Development Tools", "development-tools"),
+            ("@development-tools", "development-tools"),
+            ("@development_tools", "development-tools"),
+            ("@^minimal-environment", "minimal-environment"),
+            ("^minimal-environment", "minimal-environment"),
+            ("  @Core ", "core"),
+        ],
+    )
+    def test_normalizes_to_id(self, name: str, expected: str) -> None:
+        assert _normalize_group_name(name) == expected
+
+
+class TestMapGroup:
+    @pytest.mark.parametrize("package", ["core", "base", "development-tools"])
+    @pytest.mark.parametrize("source_distro", ["auto", "dnf", "yum"])
+    def test_bare_names_are_packages(self, package: str, source_distro: str) -> None:
+        assert _map_group(package, source_distro) is None
+
+    @pytest.mark.parametrize("package", ["@core", "^minimal-environment", "Development Tools"])
+    @pytest.mark.parametrize("source_distro", ["auto", "dnf"])
+    def test_group_spellings(self, package: str, source_distro: str) -> None:
+        result = _map_group(package, source_distro)
+        assert result is not None
+        assert result.mapping_source == "group"
+
+    def test_unknown_group(self) -> None:
+        result = _map_group("@no-such-group", "dnf")
+        assert result is not None
+        assert result.matches == []
+        assert "Unknown package group" in result.message
+
+
+class TestModuleStreamPattern:
+    @pytest.mark.parametrize(
+        "name, expected",
+        [
+            ("nodejs:18", ("nodejs", "18")),
+            ("@nodejs:18", ("nodejs", "18")),
+            ("@nodejs:18/common", ("nodejs", "18")),
+            ("postgresql:15", ("postgresql", "15")),
+            ("python39:3.9", ("python39", "3.9")),
+        ],
+    )
+    def test_module_streams(self, name: str, expected: tuple[str, str]) -> None:
+        match = _MODULE_STREAM_PATTERN.match(name)
+        assert match is not None
+        assert match.groups() == expected
+
+    @pytest.mark.parametrize("name", ["nodejs", "curl=7.88.1", "nodejs:", ":18", "a:b:c"])
+    def test_non_module_streams(self, name: str) -> None:
+        assert _MODULE_STREAM_PATTERN.match(name) is None
+
+
+class TestMappingsDigest:
+    def test_is_stable_sha256(self) -> None:
+        digest = mappings_digest()
+        assert len(digest) == 64
+        assert digest == mappings_digest()